@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// hasFlag reports whether flag appears among args.
+func hasFlag(args []string, flag string) bool {
+	for _, arg := range args {
+		if arg == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// flagValue returns the argument following flag in args. ok is false when the
+// flag is absent or has no value after it.
+func flagValue(args []string, flag string) (value string, ok bool) {
+	for i, arg := range args {
+		if arg == flag && i+1 < len(args) {
+			return args[i+1], true
+		}
+	}
+	return "", false
+}
+
+// argSpec describes the arguments a command accepts after its name: at most
+// positional plain arguments, the on/off flags in flags and the flags in
+// valueFlags that take a value. A command whose forms differ by subcommand
+// lists them in subcommands, each checked against the arguments after it.
+type argSpec struct {
+	positional  int
+	flags       []string
+	valueFlags  []string
+	subcommands map[string]argSpec
+}
+
+// commandArgs lists the argument specs checked by --strict-args. Commands that
+// pass their arguments on, such as watch, are left out and never checked.
+var commandArgs = map[string]argSpec{
+	"add": {positional: 1, subcommands: map[string]argSpec{
+		"person":       {positional: 1},
+		"relationship": {positional: 4},
+	}},
+	"remove":               {positional: 2},
+	"connect":              {positional: 5},
+	"countsons":            {positional: 1, flags: []string{"--living", "--verbose"}},
+	"countdaughters":       {positional: 1, flags: []string{"--living", "--verbose"}},
+	"countchildren":        {positional: 1, flags: []string{"--living", "--verbose"}},
+	"countbrothers":        {positional: 1, flags: []string{"--living", "--verbose"}},
+	"countsisters":         {positional: 1, flags: []string{"--living", "--verbose"}},
+	"countwives":           {positional: 1, flags: []string{"--living", "--verbose"}},
+	"countcousins":         {positional: 1, valueFlags: []string{"--degree"}},
+	"countancestors":       {positional: 1, flags: []string{"--by-generation", "--completeness"}},
+	"count":                {positional: 2, flags: []string{"--by-generation", "--list"}, valueFlags: []string{"--where", "--direction", "--depth"}},
+	"relationship-counts":  {flags: []string{"--json"}},
+	"relation-types":       {},
+	"countroots":           {flags: []string{"--json"}},
+	"countleaves":          {flags: []string{"--json"}},
+	"father":               {positional: 2, flags: []string{"--json"}},
+	"mother":               {positional: 2, flags: []string{"--json"}},
+	"grandfather":          {positional: 2},
+	"grandmother":          {positional: 2},
+	"siblings":             {positional: 2},
+	"children":             {positional: 2},
+	"grandchildren":        {positional: 2},
+	"branching":            {},
+	"verify-reciprocity":   {flags: []string{"--fix"}},
+	"tag":                  {positional: 3},
+	"find":                 {valueFlags: []string{"--tag"}},
+	"list":                 {flags: []string{"--relations"}},
+	"show":                 {positional: 1},
+	"longest-line":         {},
+	"nearest-relatives":    {positional: 2, flags: []string{"--explain"}},
+	"within":               {positional: 2, flags: []string{"--explain"}},
+	"explain":              {positional: 2},
+	"set":                  {positional: 3, flags: []string{"--no-validate"}},
+	"lifespans":            {valueFlags: []string{"--max-age"}},
+	"generation-gaps":      {valueFlags: []string{"--min-age", "--max-age"}},
+	"same-birthday":        {},
+	"export":               {positional: 2, flags: []string{"--anonymize-living", "--parents-first"}, valueFlags: []string{"--up", "--down", "--output", "--width"}},
+	"cleanup":              {flags: []string{"--orphans", "--remove"}},
+	"anonymize":            {valueFlags: []string{"--output"}},
+	"edges":                {flags: []string{"--json", "--parents-first"}},
+	"find-orphans":         {},
+	"ancestors":            {positional: 2},
+	"ancestors-at-level":   {positional: 2, flags: []string{"--with-spouses"}},
+	"lineage":              {positional: 1, flags: []string{"--with-spouses"}, valueFlags: []string{"--line"}},
+	"descendants-at-level": {positional: 2, flags: []string{"--with-spouses"}},
+	"rename-surname":       {positional: 2, valueFlags: []string{"--under"}},
+	"health":               {flags: []string{"--json"}},
+	"surnames":             {},
+	"roots":                {},
+	"remarriages":          {},
+	"stats":                {flags: []string{"--watch"}, valueFlags: []string{"--interval"}},
+	"compare-branches":     {positional: 2},
+	"tree":                 {positional: 1, flags: []string{"--json"}, valueFlags: []string{"--style"}},
+	"kinship-coefficient":  {positional: 2},
+	"check-spouses":        {flags: []string{"--monogamy"}},
+	"lint":                 {flags: []string{"--fix"}},
+	"snapshot":             {positional: 2},
+	"infer-gender":         {flags: []string{"--dry-run"}},
+	"import":               {positional: 2, valueFlags: []string{"--input-encoding"}},
+	"import-photos":        {positional: 1},
+	"normalize":            {},
+	"normalize-types":      {valueFlags: []string{"--map"}},
+	"cite":                 {positional: 2},
+}
+
+// checkArgs returns an error for the first argument of command that its spec
+// does not allow: an unknown option or one positional argument too many.
+func checkArgs(command string, args []string) error {
+	spec, ok := commandArgs[command]
+	if !ok {
+		return nil
+	}
+	if len(args) > 0 {
+		if subcommand, ok := spec.subcommands[args[0]]; ok {
+			command, spec, args = command+" "+args[0], subcommand, args[1:]
+		}
+	}
+	positional := 0
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case contains(spec.valueFlags, arg):
+			i++
+		case contains(spec.flags, arg):
+		case strings.HasPrefix(arg, "--"):
+			return fmt.Errorf("unknown option '%s' for '%s'", arg, command)
+		default:
+			positional++
+			if positional > spec.positional {
+				return fmt.Errorf("unexpected argument '%s' for '%s'", arg, command)
+			}
+		}
+	}
+	return nil
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
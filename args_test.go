@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestCheckArgsAddSubcommands(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr bool
+	}{
+		{[]string{"person", "Zed"}, false},
+		{[]string{"person", "Zed", "extra"}, true},
+		{[]string{"relationship", "Zed", "son", "of", "KK"}, false},
+		{[]string{"relationship", "Zed", "son", "of", "KK", "extra"}, true},
+		{[]string{"cousin", "Zed"}, true},
+	}
+	for _, tt := range tests {
+		err := checkArgs("add", tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("checkArgs(add, %q) = %v, want error: %v", tt.args, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// batchState holds the family tree shared by the commands of a batch. It is
+// loaded once and written once, after the last command.
+type batchState struct {
+	familyTree map[string]Person
+	modified   bool
+}
+
+// batch is non-nil while runCommands is executing a batch.
+var batch *batchState
+
+// runCommands executes one command per line read from r, all against the same
+// in-memory family tree, and saves the tree once at the end. Blank lines and
+// lines starting with '#' are skipped. A failing command exits the program,
+// so either the whole batch is saved or none of it is. r is the reader confirm
+// uses, so a command that asks a question takes its answer from the next line.
+func runCommands(r *bufio.Reader) {
+	batch = &batchState{familyTree: loadFamilyTree()}
+
+	program := os.Args[0]
+	for lineNumber := 1; ; lineNumber++ {
+		text, err := r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			fmt.Printf("Error reading commands: %v\n", err)
+			os.Exit(1)
+		}
+		if text == "" && err == io.EOF {
+			break
+		}
+
+		line := strings.TrimSpace(text)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		args, err := splitCommandLine(line)
+		if err != nil {
+			fmt.Printf("Line %d: %v. No changes were saved.\n", lineNumber, err)
+			os.Exit(1)
+		}
+		if args[0] == "-" {
+			fmt.Printf("Line %d: batches cannot be nested. No changes were saved.\n", lineNumber)
+			os.Exit(1)
+		}
+
+		os.Args = append([]string{program}, args...)
+		runCommand()
+	}
+
+	state := batch
+	batch = nil
+	if state.modified {
+		saveFamilyTree(state.familyTree)
+	}
+}
+
+// splitCommandLine splits a command line into arguments at whitespace, keeping
+// text inside single or double quotes together, so "Amit Dhakad" is a single
+// argument.
+func splitCommandLine(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	var quote rune
+	inArg := false
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// printChildren lists name's children in sorted order, each with the relation
+// recorded between them, e.g. "Ravi (son)".
+func printChildren(name string) {
+	familyTree := loadFamilyTree()
+
+	if _, exists := familyTree[name]; !exists {
+		fmt.Printf("%s is not in the family tree.\n", name)
+		os.Exit(1)
+	}
+
+	children := childrenOf(familyTree, name)
+	if len(children) == 0 {
+		fmt.Printf("%s has no children recorded.\n", name)
+		return
+	}
+
+	fmt.Printf("Children of %s:\n", name)
+	for _, child := range children {
+		fmt.Printf("  %s (%s)\n", child, childRelationType(familyTree, name, child))
+	}
+}
+
+// childRelationType returns the relation type recorded between child and
+// parent: the son, daughter or child relation the child holds, or "child" when
+// the link was only recorded on the parent's side, as in "father of".
+func childRelationType(familyTree map[string]Person, parent, child string) string {
+	for _, relation := range familyTree[child].Relations {
+		if childTypes[relation.Type] && relation.Target == parent {
+			return relation.Type
+		}
+	}
+	return "child"
+}
+
+// grandchildrenOf returns the sorted, distinct children of name's children.
+// name is never their own grandchild, even in malformed data.
+func grandchildrenOf(familyTree map[string]Person, name string) []string {
+	var grandchildren []string
+	for _, grandchild := range descendantsAtLevel(familyTree, name, 2) {
+		if grandchild != name {
+			grandchildren = append(grandchildren, grandchild)
+		}
+	}
+	return grandchildren
+}
+
+// printGrandchildren lists name's grandchildren in sorted order.
+func printGrandchildren(name string) {
+	familyTree := loadFamilyTree()
+
+	if _, exists := familyTree[name]; !exists {
+		fmt.Printf("%s is not in the family tree.\n", name)
+		os.Exit(1)
+	}
+
+	grandchildren := grandchildrenOf(familyTree, name)
+	if len(grandchildren) == 0 {
+		fmt.Printf("%s has no grandchildren recorded.\n", name)
+		return
+	}
+
+	fmt.Printf("Grandchildren of %s:\n", name)
+	for _, grandchild := range grandchildren {
+		fmt.Printf("  %s (%s)\n", grandchild, gendered(genderOf(familyTree, grandchild), "grandson", "granddaughter", "grandchild"))
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// cleanupOrphans lists people with no relationships in either direction and,
+// when remove is set, deletes them after confirmation and a backup. People
+// with children but no parents are roots, not orphans, and are kept.
+func cleanupOrphans(remove bool) {
+	familyTree := loadFamilyTree()
+
+	var orphans, roots []string
+	for _, name := range sortedNames(familyTree) {
+		if isOrphan(familyTree, name) {
+			orphans = append(orphans, name)
+		} else if len(parentsOf(familyTree, name)) == 0 && len(childrenOf(familyTree, name)) > 0 {
+			roots = append(roots, name)
+		}
+	}
+
+	if len(roots) > 0 {
+		fmt.Printf("Roots (children but no parents, kept): %s\n", strings.Join(roots, ", "))
+	}
+	if len(orphans) == 0 {
+		fmt.Println("No orphans found.")
+		return
+	}
+
+	fmt.Println("Orphans (no relationships at all):")
+	for _, name := range orphans {
+		fmt.Printf("  %s\n", name)
+	}
+
+	if !remove {
+		fmt.Println("\nThis was a dry run. Use --remove to delete them.")
+		return
+	}
+
+	checkWritable()
+	if !confirm(fmt.Sprintf("Remove %d %s from the family tree?", len(orphans), plural(len(orphans), "orphan"))) {
+		fmt.Println("Nothing removed.")
+		return
+	}
+
+	backup, err := backupFamilyTreeFile()
+	if err != nil {
+		fmt.Printf("Error backing up family tree file: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, name := range orphans {
+		delete(familyTree, name)
+	}
+	saveFamilyTree(familyTree)
+	fmt.Printf("Removed %d %s. Backup saved to %s.\n", len(orphans), plural(len(orphans), "orphan"), backup)
+}
+
+// backupFamilyTreeFile copies the family tree file, as stored on disk, to a
+// timestamped file next to it and returns the backup's path.
+func backupFamilyTreeFile() (string, error) {
+	checkWritable()
+	data, err := os.ReadFile(familyTreeFile)
+	if err != nil {
+		return "", err
+	}
+
+	backup := fmt.Sprintf("%s.%s.bak", familyTreeFile, time.Now().Format("20060102-150405"))
+	return backup, os.WriteFile(backup, data, 0644)
+}
+
+// stdin is the one buffered reader of standard input, shared by confirm and
+// by batches, so neither loses piped lines buffered by the other.
+var stdin = bufio.NewReader(os.Stdin)
+
+// confirm asks a yes/no question on the terminal and reports whether the
+// answer was yes.
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := stdin.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// findOrphans flags people who have no parents recorded although the data
+// suggests they should, as leads for further research.
+func findOrphans() {
+	familyTree := loadFamilyTree()
+
+	// Anyone born within this many years is presumed to have living parents
+	// who could be recorded.
+	const youngYears = 18
+	youngSince := time.Now().AddDate(-youngYears, 0, 0)
+
+	flagged := 0
+	for _, name := range sortedNames(familyTree) {
+		if len(parentsOf(familyTree, name)) > 0 {
+			continue
+		}
+
+		var reasons []string
+		if siblings := recordedSiblings(familyTree, name); len(siblings) > 0 {
+			reason := "has siblings recorded (" + strings.Join(siblings, ", ") + ")"
+			for _, sibling := range siblings {
+				if len(parentsOf(familyTree, sibling)) > 0 {
+					reason += ", and " + sibling + " has parents recorded"
+					break
+				}
+			}
+			reasons = append(reasons, reason)
+		}
+		if birth, ok := personDate(familyTree[name].Birth); ok && birth.earliest().After(youngSince) {
+			reasons = append(reasons, fmt.Sprintf("was born %s, less than %d years ago", familyTree[name].Birth, youngYears))
+		}
+
+		if len(reasons) > 0 {
+			fmt.Printf("%s: no parents recorded, but %s.\n", name, strings.Join(reasons, "; "))
+			flagged++
+		}
+	}
+
+	if flagged == 0 {
+		fmt.Println("No one looks like they are missing parents.")
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// branchStats summarises the subtree rooted at a person.
+type branchStats struct {
+	descendants   int
+	generations   int
+	earliest      string
+	latest        string
+	earliestDate  genealogyDate
+	latestDate    genealogyDate
+	haveDateRange bool
+}
+
+func computeBranchStats(familyTree map[string]Person, root string) branchStats {
+	var stats branchStats
+	depths := descendantDepths(familyTree, root)
+	stats.descendants = len(depths)
+
+	members := []string{root}
+	for name, depth := range depths {
+		members = append(members, name)
+		if depth > stats.generations {
+			stats.generations = depth
+		}
+	}
+
+	for _, name := range members {
+		for _, value := range []string{familyTree[name].Birth, familyTree[name].Death} {
+			date, ok := personDate(value)
+			if !ok {
+				continue
+			}
+			if !stats.haveDateRange || date.earliest().Before(stats.earliestDate.earliest()) {
+				stats.earliest, stats.earliestDate = value, date
+			}
+			if !stats.haveDateRange || date.latest().After(stats.latestDate.latest()) {
+				stats.latest, stats.latestDate = value, date
+			}
+			stats.haveDateRange = true
+		}
+	}
+	return stats
+}
+
+// compareBranches prints the subtrees rooted at two people side by side.
+func compareBranches(name1, name2 string) {
+	familyTree := loadFamilyTree()
+
+	for _, name := range []string{name1, name2} {
+		if _, exists := familyTree[name]; !exists {
+			fmt.Printf("%s is not in the family tree.\n", name)
+			os.Exit(1)
+		}
+	}
+
+	a, b := computeBranchStats(familyTree, name1), computeBranchStats(familyTree, name2)
+	rows := [][3]string{
+		{"", name1, name2},
+		{"Descendants", fmt.Sprint(a.descendants), fmt.Sprint(b.descendants)},
+		{"Generations", fmt.Sprint(a.generations), fmt.Sprint(b.generations)},
+		{"Earliest date", orDash(a.earliest), orDash(b.earliest)},
+		{"Latest date", orDash(a.latest), orDash(b.latest)},
+	}
+
+	// Size the columns to their widest cell so long names and large counts
+	// stay aligned.
+	var widths [3]int
+	for _, row := range rows {
+		for i, cell := range row {
+			if len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+	for _, row := range rows {
+		fmt.Printf("%-*s  %*s  %*s\n", widths[0], row[0], widths[1], row[1], widths[2], row[2])
+	}
+
+	switch {
+	case a.descendants > 0 && b.descendants > 0 && a.descendants >= 2*b.descendants:
+		fmt.Printf("\n%s's branch is %.1f times the size of %s's.\n", name1, float64(a.descendants)/float64(b.descendants), name2)
+	case a.descendants > 0 && b.descendants > 0 && b.descendants >= 2*a.descendants:
+		fmt.Printf("\n%s's branch is %.1f times the size of %s's.\n", name2, float64(b.descendants)/float64(a.descendants), name1)
+	}
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// countDescendantsByGeneration returns how many descendants name has at each
+// generation below them, keyed by generation (1 for children).
+func countDescendantsByGeneration(name string) (map[int]int, error) {
+	familyTree := loadFamilyTree()
+
+	if _, exists := familyTree[name]; !exists {
+		return nil, personNotFound(name)
+	}
+
+	counts := make(map[int]int)
+	for _, depth := range descendantDepths(familyTree, name) {
+		counts[depth]++
+	}
+	return counts, nil
+}
+
+// printGenerationCounts prints a per-generation table followed by the total.
+func printGenerationCounts(counts map[int]int, label func(int) string) {
+	deepest, total := 0, 0
+	for generation, count := range counts {
+		total += count
+		if generation > deepest {
+			deepest = generation
+		}
+	}
+
+	fmt.Printf("%-10s  %-28s  %s\n", "Generation", "Relatives", "Count")
+	for generation := 1; generation <= deepest; generation++ {
+		fmt.Printf("%-10d  %-28s  %d\n", generation, label(generation), counts[generation])
+	}
+	fmt.Printf("Total: %d\n", total)
+}
+
+// descendantGenerationName names the descendants n generations down:
+// children, grandchildren, great-grandchildren and so on.
+func descendantGenerationName(n int) string {
+	if n == 1 {
+		return "children"
+	}
+	return strings.Repeat("great-", n-2) + "grandchildren"
+}
+
+// countAncestorsByGeneration returns how many ancestors name has at each
+// generation above them, keyed by generation (1 for parents). Ancestors
+// reached by several paths are counted once, at their nearest generation.
+func countAncestorsByGeneration(name string) (map[int]int, error) {
+	familyTree := loadFamilyTree()
+
+	if _, exists := familyTree[name]; !exists {
+		return nil, personNotFound(name)
+	}
+
+	counts := make(map[int]int)
+	for ancestor, generation := range ancestorDistances(familyTree, name) {
+		if ancestor != name {
+			counts[generation]++
+		}
+	}
+	return counts, nil
+}
+
+// printAncestorCompleteness compares the ancestors found in each generation
+// with the 2^n a person can have n generations up.
+func printAncestorCompleteness(name string, counts map[int]int, byGeneration bool) {
+	deepest, found, possible := 0, 0, 0
+	for generation := range counts {
+		if generation > deepest {
+			deepest = generation
+		}
+	}
+	if deepest == 0 {
+		fmt.Printf("%s has no ancestors recorded.\n", name)
+		return
+	}
+
+	if byGeneration {
+		fmt.Printf("%-10s  %-28s  %-8s  %s\n", "Generation", "Relatives", "Found", "Complete")
+	}
+	for generation := 1; generation <= deepest; generation++ {
+		slots := 1 << generation
+		found += counts[generation]
+		possible += slots
+		if byGeneration {
+			fmt.Printf("%-10d  %-28s  %-8s  %.0f%%\n", generation, ancestorGenerationName(generation),
+				fmt.Sprintf("%d/%d", counts[generation], slots), percent(counts[generation], slots))
+		}
+	}
+
+	fmt.Printf("%s has %d of a possible %d ancestors in %d %s (%.0f%%).\n",
+		name, found, possible, deepest, plural(deepest, "generation"), percent(found, possible))
+}
+
+// relationshipCount is one row of the relationship-counts table.
+type relationshipCount struct {
+	Type  string `json:"type"`
+	Count int    `json:"count"`
+}
+
+// relationTypeCounts tallies the relations of each type held across the
+// whole tree, most common first.
+func relationTypeCounts(familyTree map[string]Person) []relationshipCount {
+	tally := make(map[string]int)
+	for _, person := range familyTree {
+		for _, relation := range person.Relations {
+			tally[relation.Type]++
+		}
+	}
+
+	counts := []relationshipCount{}
+	for relationType, count := range tally {
+		counts = append(counts, relationshipCount{relationType, count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Type < counts[j].Type
+	})
+	return counts
+}
+
+// relationshipCounts prints the relationTypeCounts table. Because both
+// partners hold their own side of a link, uneven pairs such as many more
+// "father" than "child" relations point at missing reciprocals.
+func relationshipCounts(asJSON bool) {
+	familyTree := loadFamilyTree()
+	counts := relationTypeCounts(familyTree)
+
+	if asJSON {
+		data, err := json.MarshalIndent(counts, "", "  ")
+		if err != nil {
+			fmt.Printf("Error encoding family tree data: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	if len(counts) == 0 {
+		fmt.Println("No relationships are recorded.")
+		return
+	}
+	total := 0
+	fmt.Printf("%-12s  %s\n", "Type", "Count")
+	for _, c := range counts {
+		fmt.Printf("%-12s  %d\n", c.Type, c.Count)
+		total += c.Count
+	}
+	fmt.Printf("Total: %d\n", total)
+	noteSeparateFamilies(familyTree)
+}
+
+// printRelationTypes lists the distinct relation types in use, most common
+// first, marking any the program does not recognise so typos stand out
+// before normalize-types is run.
+func printRelationTypes() {
+	counts := relationTypeCounts(loadFamilyTree())
+	if len(counts) == 0 {
+		fmt.Println("No relationships are recorded.")
+		return
+	}
+
+	unrecognised := 0
+	for _, c := range counts {
+		if inverseRelation(c.Type) == "" {
+			fmt.Printf("%5d  %s (unrecognised)\n", c.Count, c.Type)
+			unrecognised++
+		} else {
+			fmt.Printf("%5d  %s\n", c.Count, c.Type)
+		}
+	}
+	if unrecognised > 0 {
+		fmt.Printf("\n%d unrecognised %s; see normalize-types.\n", unrecognised, plural(unrecognised, "type"))
+	}
+}
+
+// countRelativesAtDepth counts the ancestors (direction "up") or descendants
+// (direction "down") of name exactly depth generations away. Depth 0 is
+// name alone.
+func countRelativesAtDepth(name, direction string, depth int) (int, error) {
+	familyTree := loadFamilyTree()
+
+	if _, exists := familyTree[name]; !exists {
+		return 0, personNotFound(name)
+	}
+	if depth == 0 {
+		return 1, nil
+	}
+	if direction == "up" {
+		return len(ancestorsAtLevel(familyTree, name, depth)), nil
+	}
+	return len(descendantsAtLevel(familyTree, name, depth)), nil
+}
+
+// countWithout counts the people for whom step finds no one: parentsOf gives
+// the roots of the tree and childrenOf its leaves.
+func countWithout(step func(map[string]Person, string) []string) int {
+	familyTree := loadFamilyTree()
+	count := 0
+	for name := range familyTree {
+		if len(step(familyTree, name)) == 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// printCount prints a single-number metric, as {"key": n} with asJSON.
+func printCount(key string, n int, asJSON bool) {
+	if asJSON {
+		fmt.Printf("{%q:%d}\n", key, n)
+		return
+	}
+	fmt.Println(n)
+}
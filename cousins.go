@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+func countCousins(name string, degree int) (int, error) {
+	familyTree := loadFamilyTree()
+
+	if _, exists := familyTree[name]; exists {
+		return len(cousinsOf(familyTree, name, degree)), nil
+	}
+
+	return 0, personNotFound(name)
+}
+
+var ordinals = []string{"zeroth", "first", "second", "third", "fourth", "fifth", "sixth", "seventh", "eighth", "ninth", "tenth"}
+
+// ordinal spells out small ordinal numbers ("first", "second", ...) and falls
+// back to "11th" style for larger ones.
+func ordinal(n int) string {
+	if n >= 0 && n < len(ordinals) {
+		return ordinals[n]
+	}
+	suffix := "th"
+	if n%100 < 11 || n%100 > 13 {
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
+var irregularPlurals = map[string]string{"person": "people", "child": "children", "wife": "wives"}
+
+// plural returns word, in its plural form unless count is exactly one.
+// Compounds follow their last part, so "grandchild" becomes "grandchildren".
+func plural(count int, word string) string {
+	if count == 1 {
+		return word
+	}
+	for singular, irregular := range irregularPlurals {
+		if strings.HasSuffix(word, singular) {
+			return strings.TrimSuffix(word, singular) + irregular
+		}
+	}
+	return word + "s"
+}
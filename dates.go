@@ -0,0 +1,403 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// genealogyDate is a date that may only be known to the year or the month,
+// and may be approximate. Month and Day are zero when unknown.
+type genealogyDate struct {
+	Year, Month, Day int
+	Approximate      bool
+}
+
+var (
+	datePattern        = regexp.MustCompile(`^(\d{4})(?:-(\d{2})(?:-(\d{2}))?)?$`)
+	approximatePattern = regexp.MustCompile(`(?i)^(?:~|abt\.?)\s*`)
+)
+
+// approximateYears is how far either way an approximate date may be from
+// the recorded one.
+const approximateYears = 5
+
+// parseDate accepts YYYY, YYYY-MM or YYYY-MM-DD, optionally prefixed with "~"
+// or "abt" to mark it approximate, and rejects impossible months and days
+// such as 1850-13 or 1850-02-30.
+func parseDate(value string) (genealogyDate, error) {
+	var date genealogyDate
+	exact := value
+	if prefix := approximatePattern.FindString(value); prefix != "" {
+		date.Approximate = true
+		exact = value[len(prefix):]
+	}
+
+	match := datePattern.FindStringSubmatch(exact)
+	if match == nil {
+		return genealogyDate{}, fmt.Errorf("invalid date '%s'; use YYYY, YYYY-MM or YYYY-MM-DD, optionally prefixed with ~ or abt", value)
+	}
+
+	date.Year, _ = strconv.Atoi(match[1])
+	if match[2] != "" {
+		date.Month, _ = strconv.Atoi(match[2])
+		if date.Month < 1 || date.Month > 12 {
+			return genealogyDate{}, fmt.Errorf("invalid month in date '%s'", value)
+		}
+	}
+	if match[3] != "" {
+		date.Day, _ = strconv.Atoi(match[3])
+		if date.Day < 1 || date.Day > daysIn(date.Year, date.Month) {
+			return genealogyDate{}, fmt.Errorf("invalid day in date '%s'", value)
+		}
+	}
+	return date, nil
+}
+
+func daysIn(year, month int) int {
+	return time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// personDate parses a stored birth or death date; ok is false when the date
+// is missing or unreadable.
+func personDate(value string) (date genealogyDate, ok bool) {
+	date, err := parseDate(value)
+	return date, err == nil
+}
+
+// earliest returns the first day the date could refer to.
+func (d genealogyDate) earliest() time.Time {
+	month, day := d.Month, d.Day
+	if month == 0 {
+		month = 1
+	}
+	if day == 0 {
+		day = 1
+	}
+	t := time.Date(d.Year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if d.Approximate {
+		t = t.AddDate(-approximateYears, 0, 0)
+	}
+	return t
+}
+
+// latest returns the last day the date could refer to.
+func (d genealogyDate) latest() time.Time {
+	month, day := d.Month, d.Day
+	if month == 0 {
+		month = 12
+	}
+	if day == 0 {
+		day = daysIn(d.Year, month)
+	}
+	t := time.Date(d.Year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if d.Approximate {
+		t = t.AddDate(approximateYears, 0, 0)
+	}
+	return t
+}
+
+// String formats the date for storage: the date itself, prefixed with "abt "
+// when it is approximate.
+func (d genealogyDate) String() string {
+	value := fmt.Sprintf("%04d", d.Year)
+	if d.Month != 0 {
+		value += fmt.Sprintf("-%02d", d.Month)
+	}
+	if d.Day != 0 {
+		value += fmt.Sprintf("-%02d", d.Day)
+	}
+	if d.Approximate {
+		value = "abt " + value
+	}
+	return value
+}
+
+// displayDate formats a stored date for reports, showing approximate dates
+// as "c. 1850". Unreadable values are shown as stored.
+func displayDate(value string) string {
+	date, ok := personDate(value)
+	if !ok || !date.Approximate {
+		return value
+	}
+	date.Approximate = false
+	return "c. " + date.String()
+}
+
+// certainlyBefore reports whether a is before b however the unknown parts of
+// either date turn out.
+func certainlyBefore(a, b genealogyDate) bool {
+	return a.latest().Before(b.earliest())
+}
+
+func setDate(field, name, value string, validate bool) {
+	date, err := parseDate(value)
+	if err != nil {
+		fmt.Printf("Invalid date: %v.\n", err)
+		os.Exit(1)
+	}
+
+	familyTree := loadFamilyTree()
+
+	person, exists := familyTree[name]
+	if !exists {
+		fmt.Printf("%s is not in the family tree.\n", name)
+		os.Exit(1)
+	}
+
+	if validate {
+		for _, warning := range chronologyWarnings(familyTree, name, field, date) {
+			fmt.Printf("Warning: %s\n", warning)
+		}
+	}
+
+	// Store a canonical form, so "~1850" and "ABT 1850" both become "abt 1850".
+	if field == "birth" {
+		person.Birth = date.String()
+	} else {
+		person.Death = date.String()
+	}
+	familyTree[name] = person
+	saveFamilyTree(familyTree)
+
+	fmt.Printf("Set %s's %s date to %s.\n", name, field, displayDate(date.String()))
+}
+
+// chronologyWarnings cross-checks a new birth or death date for name against
+// their own dates and those of their parents and children. Partial dates
+// only produce a warning when every reading of them conflicts.
+func chronologyWarnings(familyTree map[string]Person, name, field string, date genealogyDate) []string {
+	var warnings []string
+	person := familyTree[name]
+	// A child can be born up to nine months after the father's death.
+	const gestation = 9
+
+	if field == "birth" {
+		if death, ok := personDate(person.Death); ok && certainlyBefore(death, date) {
+			warnings = append(warnings, fmt.Sprintf("%s would be born after their own death (%s).", name, person.Death))
+		}
+		for _, child := range childrenOf(familyTree, name) {
+			if birth, ok := personDate(familyTree[child].Birth); ok && certainlyBefore(birth, date) {
+				warnings = append(warnings, fmt.Sprintf("%s would be born after their child %s (born %s).", name, child, familyTree[child].Birth))
+			}
+		}
+		for _, parent := range parentsOf(familyTree, name) {
+			if birth, ok := personDate(familyTree[parent].Birth); ok && certainlyBefore(date, birth) {
+				warnings = append(warnings, fmt.Sprintf("%s would be born before their parent %s (born %s).", name, parent, familyTree[parent].Birth))
+			}
+			if death, ok := personDate(familyTree[parent].Death); ok && date.earliest().After(death.latest().AddDate(0, gestation, 0)) {
+				warnings = append(warnings, fmt.Sprintf("%s would be born more than nine months after their parent %s died (%s).", name, parent, familyTree[parent].Death))
+			}
+		}
+		return warnings
+	}
+
+	if birth, ok := personDate(person.Birth); ok && certainlyBefore(date, birth) {
+		warnings = append(warnings, fmt.Sprintf("%s would die before their own birth (%s).", name, person.Birth))
+	}
+	for _, child := range childrenOf(familyTree, name) {
+		if birth, ok := personDate(familyTree[child].Birth); ok && birth.earliest().After(date.latest().AddDate(0, gestation, 0)) {
+			warnings = append(warnings, fmt.Sprintf("%s would die more than nine months before their child %s was born (%s).", name, child, familyTree[child].Birth))
+		}
+	}
+	return warnings
+}
+
+// ageAt returns the number of whole years between birth and date. When the
+// months or days needed to tell are unknown, it is the difference in years.
+func ageAt(birth, date genealogyDate) int {
+	age := date.Year - birth.Year
+	if birth.Month == 0 || date.Month == 0 {
+		return age
+	}
+	if date.Month < birth.Month || (date.Month == birth.Month && birth.Day != 0 && date.Day != 0 && date.Day < birth.Day) {
+		age--
+	}
+	return age
+}
+
+// lifespans lists the age at death of everyone with both dates recorded,
+// only those younger than maxAge when it is positive, followed by overall
+// statistics.
+func lifespans(maxAge int) {
+	familyTree := loadFamilyTree()
+
+	type lifespan struct {
+		name        string
+		age         int
+		approximate bool
+	}
+	var spans []lifespan
+	skipped := 0
+	for _, name := range sortedNames(familyTree) {
+		person := familyTree[name]
+		birth, ok1 := personDate(person.Birth)
+		death, ok2 := personDate(person.Death)
+		if !ok1 || !ok2 {
+			skipped++
+			continue
+		}
+		spans = append(spans, lifespan{name, ageAt(birth, death), birth.Approximate || death.Approximate})
+	}
+
+	if len(spans) == 0 {
+		fmt.Println("No one has both a birth and a death date recorded.")
+		return
+	}
+
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].age < spans[j].age })
+
+	if maxAge > 0 {
+		fmt.Printf("Died before the age of %d:\n", maxAge)
+	} else {
+		fmt.Println("Age at death:")
+	}
+	listed, total := 0, 0
+	for _, span := range spans {
+		total += span.age
+		if maxAge > 0 && span.age >= maxAge {
+			continue
+		}
+		if span.approximate {
+			fmt.Printf("  %-25s c. %d\n", span.name, span.age)
+		} else {
+			fmt.Printf("  %-25s %d\n", span.name, span.age)
+		}
+		listed++
+	}
+	if listed == 0 {
+		fmt.Println("  (none)")
+	}
+
+	shortest, longest := spans[0], spans[len(spans)-1]
+	fmt.Printf("\nLifespans known: %d\n", len(spans))
+	fmt.Printf("Shortest: %d years (%s)\n", shortest.age, shortest.name)
+	fmt.Printf("Longest: %d years (%s)\n", longest.age, longest.name)
+	fmt.Printf("Average: %.1f years\n", float64(total)/float64(len(spans)))
+	if skipped > 0 {
+		fmt.Printf("Skipped %d without both dates.\n", skipped)
+	}
+}
+
+// parseMaxAge reads the optional "--max-age N" arguments of lifespans.
+func parseMaxAge(args []string) int {
+	if len(args) == 0 {
+		return 0
+	}
+	if len(args) < 2 || args[0] != "--max-age" {
+		fmt.Println("Usage: family-tree lifespans [--max-age N]")
+		os.Exit(1)
+	}
+	n, err := strconv.Atoi(args[1])
+	if err != nil || n < 1 {
+		fmt.Println("The --max-age option requires a positive number.")
+		os.Exit(1)
+	}
+	return n
+}
+
+// generationGaps reports parent and child pairs where the parent's age at the
+// child's birth is below minAge or above maxAge, which usually points to a
+// data entry mistake. Pairs without both birth dates are skipped.
+func generationGaps(minAge, maxAge int) {
+	familyTree := loadFamilyTree()
+
+	flagged := 0
+	for _, child := range sortedNames(familyTree) {
+		childBirth, ok := personDate(familyTree[child].Birth)
+		if !ok {
+			continue
+		}
+		for _, parent := range parentsOf(familyTree, child) {
+			parentBirth, ok := personDate(familyTree[parent].Birth)
+			if !ok {
+				continue
+			}
+			age := ageAt(parentBirth, childBirth)
+			if age >= minAge && age <= maxAge {
+				continue
+			}
+			gap := fmt.Sprint(age)
+			if parentBirth.Approximate || childBirth.Approximate {
+				gap = "c. " + gap
+			}
+			problem := "young"
+			if age > maxAge {
+				problem = "old"
+			}
+			fmt.Printf("%s was %s at the birth of %s (%s born %s, %s born %s): implausibly %s.\n",
+				parent, gap, child, parent, displayDate(familyTree[parent].Birth), child, displayDate(familyTree[child].Birth), problem)
+			flagged++
+		}
+	}
+
+	if flagged == 0 {
+		fmt.Printf("No parent was younger than %d or older than %d at a child's birth.\n", minAge, maxAge)
+		return
+	}
+	fmt.Printf("\n%d implausible %s found.\n", flagged, plural(flagged, "gap"))
+}
+
+// parseAgeLimits reads the optional "--min-age N" and "--max-age N" arguments
+// of generation-gaps, defaulting to 12 and 65.
+func parseAgeLimits(args []string) (minAge, maxAge int) {
+	minAge, maxAge = 12, 65
+	for flag, target := range map[string]*int{"--min-age": &minAge, "--max-age": &maxAge} {
+		if value, ok := flagValue(args, flag); ok {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				fmt.Printf("The %s option requires a number of years.\n", flag)
+				os.Exit(1)
+			}
+			*target = n
+		}
+	}
+	if minAge > maxAge {
+		fmt.Println("The --min-age must not be greater than --max-age.")
+		os.Exit(1)
+	}
+	return minAge, maxAge
+}
+
+// sameBirthday prints groups of people born on the same month and day, in
+// calendar order. Birth dates without a month and day are left out.
+func sameBirthday() {
+	familyTree := loadFamilyTree()
+
+	groups := make(map[[2]int][]string)
+	for _, name := range sortedNames(familyTree) {
+		birth, ok := personDate(familyTree[name].Birth)
+		if !ok || birth.Day == 0 || birth.Approximate {
+			continue
+		}
+		key := [2]int{birth.Month, birth.Day}
+		groups[key] = append(groups[key], name)
+	}
+
+	var days [][2]int
+	for day, names := range groups {
+		if len(names) > 1 {
+			days = append(days, day)
+		}
+	}
+	if len(days) == 0 {
+		fmt.Println("No two people share a recorded birthday.")
+		return
+	}
+	sort.Slice(days, func(i, j int) bool {
+		if days[i][0] != days[j][0] {
+			return days[i][0] < days[j][0]
+		}
+		return days[i][1] < days[j][1]
+	})
+
+	for _, day := range days {
+		fmt.Printf("%s %d:\n", time.Month(day[0]), day[1])
+		for _, name := range groups[day] {
+			fmt.Printf("  %s (born %s)\n", name, familyTree[name].Birth)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/unicode/norm"
+)
+
+// inputEncodings lists the encodings accepted by --input-encoding, with the
+// function that transcodes each one to UTF-8. UTF-8 input is used unchanged.
+var inputEncodings = map[string]func([]byte) ([]byte, error){
+	"utf-8":  nil,
+	"latin1": decodeLatin1,
+	"ansel":  decodeANSEL,
+}
+
+// transcode converts data in the named encoding to UTF-8. converted is false
+// when the data was already UTF-8 and left alone.
+func transcode(data []byte, encoding string) (result []byte, converted bool, err error) {
+	decode, ok := inputEncodings[strings.ToLower(encoding)]
+	if !ok {
+		names := make([]string, 0, len(inputEncodings))
+		for name := range inputEncodings {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, false, fmt.Errorf("unknown encoding %q, expected one of %s", encoding, strings.Join(names, ", "))
+	}
+	if decode == nil {
+		if !utf8.Valid(data) {
+			return nil, false, fmt.Errorf("the file is not valid UTF-8; try --input-encoding latin1 or ansel")
+		}
+		return data, false, nil
+	}
+	result, err = decode(data)
+	if err != nil {
+		return nil, false, err
+	}
+	return result, true, nil
+}
+
+func decodeLatin1(data []byte) ([]byte, error) {
+	return charmap.ISO8859_1.NewDecoder().Bytes(data)
+}
+
+// anselCharacters maps the spacing characters of ANSEL (ANSI Z39.47), the
+// character set of older GEDCOM files, to Unicode. Bytes below 0x80 are
+// ASCII.
+var anselCharacters = map[byte]rune{
+	0xA1: 'Ł', 0xA2: 'Ø', 0xA3: 'Đ', 0xA4: 'Þ', 0xA5: 'Æ', 0xA6: 'Œ',
+	0xA7: 'ʹ', 0xA8: '·', 0xA9: '♭', 0xAA: '®', 0xAB: '±', 0xAC: 'Ơ',
+	0xAD: 'Ư', 0xAE: 'ʼ', 0xB0: 'ʻ', 0xB1: 'ł', 0xB2: 'ø', 0xB3: 'đ',
+	0xB4: 'þ', 0xB5: 'æ', 0xB6: 'œ', 0xB7: 'ʺ', 0xB8: 'ı', 0xB9: '£',
+	0xBA: 'ð', 0xBC: 'ơ', 0xBD: 'ư', 0xC0: '°', 0xC1: 'ℓ', 0xC2: '℗',
+	0xC3: '©', 0xC4: '♯', 0xC5: '¿', 0xC6: '¡', 0xCF: 'ß',
+}
+
+// anselDiacritics maps the ANSEL combining diacritics to Unicode combining
+// marks. In ANSEL a diacritic comes before the letter it sits on, while in
+// Unicode it comes after.
+var anselDiacritics = map[byte]rune{
+	0xE0: '\u0309', 0xE1: '\u0300', 0xE2: '\u0301', 0xE3: '\u0302',
+	0xE4: '\u0303', 0xE5: '\u0304', 0xE6: '\u0306', 0xE7: '\u0307',
+	0xE8: '\u0308', 0xE9: '\u030C', 0xEA: '\u030A', 0xEB: '\uFE20',
+	0xEC: '\uFE21', 0xED: '\u0315', 0xEE: '\u030B', 0xEF: '\u0310',
+	0xF0: '\u0327', 0xF1: '\u0328', 0xF2: '\u0323', 0xF3: '\u0324',
+	0xF4: '\u0325', 0xF5: '\u0333', 0xF6: '\u0332', 0xF7: '\u0326',
+	0xF8: '\u031C', 0xF9: '\u032E', 0xFA: '\uFE22', 0xFB: '\uFE23',
+	0xFE: '\u0313',
+}
+
+// decodeANSEL converts ANSEL to UTF-8, moving each diacritic after its
+// letter and composing the pair where Unicode has a single character for it,
+// so "\xE2e" becomes "é".
+func decodeANSEL(data []byte) ([]byte, error) {
+	var out strings.Builder
+	var pending []rune
+	for i, b := range data {
+		if mark, ok := anselDiacritics[b]; ok {
+			pending = append(pending, mark)
+			continue
+		}
+		switch r, ok := anselCharacters[b]; {
+		case b < 0x80:
+			out.WriteByte(b)
+		case ok:
+			out.WriteRune(r)
+		default:
+			return nil, fmt.Errorf("byte 0x%02X at offset %d is not a valid ANSEL character", b, i)
+		}
+		for _, mark := range pending {
+			out.WriteRune(mark)
+		}
+		pending = pending[:0]
+	}
+	return norm.NFC.Bytes([]byte(out.String())), nil
+}
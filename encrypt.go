@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+// Encrypted family tree files start with encryptedMagic, followed by the
+// scrypt salt, the AES-GCM nonce and the sealed JSON data.
+const encryptedMagic = "FAMILYTREE-AESGCM-1\n"
+
+const passphraseEnv = "FAMILY_TREE_PASSPHRASE"
+
+const saltSize = 16
+
+var cachedPassphrase string
+
+func isEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(encryptedMagic))
+}
+
+func encryptData(plaintext []byte) ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	gcm, err := newCipher(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	out := []byte(encryptedMagic)
+	out = append(out, salt...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, plaintext, []byte(encryptedMagic)), nil
+}
+
+func decryptData(data []byte) ([]byte, error) {
+	data = data[len(encryptedMagic):]
+	if len(data) < saltSize {
+		return nil, errors.New("encrypted family tree file is truncated")
+	}
+	salt, data := data[:saltSize], data[saltSize:]
+
+	gcm, err := newCipher(salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("encrypted family tree file is truncated")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, []byte(encryptedMagic))
+	if err != nil {
+		return nil, errors.New("wrong passphrase or corrupted family tree file")
+	}
+	return plaintext, nil
+}
+
+// newCipher derives an AES-256 key from the passphrase and salt.
+func newCipher(salt []byte) (cipher.AEAD, error) {
+	pass, err := passphrase()
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := scrypt.Key([]byte(pass), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// passphrase returns the passphrase from FAMILY_TREE_PASSPHRASE, or prompts
+// for it on the terminal. The answer is cached for the rest of the run.
+func passphrase() (string, error) {
+	if cachedPassphrase != "" {
+		return cachedPassphrase, nil
+	}
+
+	if pass := os.Getenv(passphraseEnv); pass != "" {
+		cachedPassphrase = pass
+		return pass, nil
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("family tree file is encrypted; set %s to provide the passphrase", passphraseEnv)
+	}
+
+	fmt.Fprint(os.Stderr, "Passphrase: ")
+	pass, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if len(pass) == 0 {
+		return "", errors.New("passphrase must not be empty")
+	}
+
+	cachedPassphrase = string(pass)
+	return cachedPassphrase, nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// explainStep is one link walked when explaining a relationship: to is the
+// relation of from.
+type explainStep struct {
+	from, relation, to string
+}
+
+// explainRelationship prints a sentence saying what b is to a and how the
+// relationship runs, e.g. "Ravi is Amit's paternal grandfather: Amit's
+// father is KK, and his father is Ravi."
+func explainRelationship(a, b string) {
+	familyTree := loadFamilyTree()
+	for _, name := range []string{a, b} {
+		if _, exists := familyTree[name]; !exists {
+			fmt.Printf("%s is not in the family tree.\n", name)
+			os.Exit(1)
+		}
+	}
+	if a == b {
+		fmt.Println("Please name two different people.")
+		os.Exit(1)
+	}
+
+	label := kinshipLabel(familyTree, a, b)
+	steps := explainSteps(familyTree, a, b, label)
+	if label == "" || steps == nil {
+		fmt.Printf("No relationship between %s and %s can be worked out from the family tree.\n", a, b)
+		return
+	}
+
+	// A grandparent, uncle or aunt is on the side of the first parent walked through.
+	if len(steps) > 1 && parentTypes[steps[0].relation] && !strings.Contains(label, "in-law") &&
+		(strings.Contains(label, "grand") || strings.Contains(label, "uncle") || strings.Contains(label, "aunt")) {
+		switch genderOf(familyTree, steps[0].to) {
+		case "male":
+			label = "paternal " + label
+		case "female":
+			label = "maternal " + label
+		}
+	}
+
+	sentence := fmt.Sprintf("%s is %s's %s", b, a, label)
+	if len(steps) == 1 {
+		fmt.Println(sentence + ".")
+		return
+	}
+
+	clauses := make([]string, len(steps))
+	for i, step := range steps {
+		owner := step.from + "'s"
+		if i > 0 {
+			owner = possessive(familyTree, step.from)
+		}
+		clauses[i] = fmt.Sprintf("%s %s is %s", owner, step.relation, step.to)
+	}
+	clauses[len(clauses)-1] = "and " + clauses[len(clauses)-1]
+	fmt.Printf("%s: %s.\n", sentence, strings.Join(clauses, ", "))
+}
+
+// explainSteps finds the links from a to b behind label: a line of blood
+// through the nearest common ancestor, a marriage followed or preceded by
+// such a line, or a single direct relation. It returns nil when there is no
+// connection.
+func explainSteps(familyTree map[string]Person, a, b, label string) []explainStep {
+	if steps := bloodSteps(familyTree, a, b); steps != nil {
+		return steps
+	}
+	for _, spouse := range spousesOf(familyTree, a) {
+		marriage := explainStep{a, spouseWord(familyTree, spouse), spouse}
+		if spouse == b {
+			return []explainStep{marriage}
+		}
+		if steps := bloodSteps(familyTree, spouse, b); steps != nil {
+			return append([]explainStep{marriage}, steps...)
+		}
+	}
+	for _, spouse := range spousesOf(familyTree, b) {
+		if steps := bloodSteps(familyTree, a, spouse); steps != nil {
+			return append(steps, explainStep{spouse, spouseWord(familyTree, b), b})
+		}
+	}
+	if label != "" {
+		return []explainStep{{a, label, b}}
+	}
+	return nil
+}
+
+// bloodSteps walks from a up to the nearest ancestor shared with b and down
+// again to b. It returns nil when a and b are not blood relatives.
+func bloodSteps(familyTree map[string]Person, a, b string) []explainStep {
+	ancestor, _, _, ok := commonAncestor(familyTree, a, b)
+	if !ok {
+		return nil
+	}
+
+	var steps []explainStep
+	up := lineTo(familyTree, a, ancestor)
+	for i := 1; i < len(up); i++ {
+		steps = append(steps, explainStep{up[i-1], gendered(genderOf(familyTree, up[i]), "father", "mother", "parent"), up[i]})
+	}
+	down := lineTo(familyTree, b, ancestor)
+	for i := len(down) - 1; i > 0; i-- {
+		steps = append(steps, explainStep{down[i], gendered(genderOf(familyTree, down[i-1]), "son", "daughter", "child"), down[i-1]})
+	}
+	return steps
+}
+
+// lineTo returns the shortest line of ascent from name to ancestor, starting
+// with name and ending with ancestor, or nil when ancestor is not above name.
+func lineTo(familyTree map[string]Person, name, ancestor string) []string {
+	previous := map[string]string{name: ""}
+	queue := []string{name}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == ancestor {
+			var line []string
+			for person := ancestor; person != ""; person = previous[person] {
+				line = append([]string{person}, line...)
+			}
+			return line
+		}
+		for _, parent := range parentsOf(familyTree, current) {
+			if _, seen := previous[parent]; !seen {
+				previous[parent] = current
+				queue = append(queue, parent)
+			}
+		}
+	}
+	return nil
+}
+
+func spouseWord(familyTree map[string]Person, name string) string {
+	return gendered(genderOf(familyTree, name), "husband", "wife", "spouse")
+}
+
+// possessive refers back to name with a pronoun when their gender is known,
+// and by name otherwise.
+func possessive(familyTree map[string]Person, name string) string {
+	return gendered(genderOf(familyTree, name), "his", "her", name+"'s")
+}
@@ -0,0 +1,500 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// nestedNode is one person in the nested export, with their descendants
+// inlined. A person reached a second time (through a cycle or a second
+// parent) is emitted once more with Revisited set and no children.
+type nestedNode struct {
+	Name      string        `json:"name"`
+	Children  []*nestedNode `json:"children,omitempty"`
+	Revisited bool          `json:"revisited,omitempty"`
+}
+
+func exportNested(name string, anonymizeLiving, parentsFirstOrder bool) {
+	familyTree := loadFamilyTree()
+
+	if _, exists := familyTree[name]; !exists {
+		fmt.Printf("%s is not in the family tree.\n", name)
+		os.Exit(1)
+	}
+
+	root, revisits := buildNested(familyTree, name, anonymizeLiving)
+	if parentsFirstOrder {
+		root, revisits = buildNestedParentsFirst(familyTree, name, anonymizeLiving)
+	}
+
+	data, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		fmt.Printf("Error encoding family tree data: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(data))
+
+	if revisits > 0 {
+		fmt.Fprintf(os.Stderr, "Note: %d %s reached more than once, marked \"revisited\".\n", revisits, plural(revisits, "node"))
+	}
+}
+
+// buildNested collects name and their descendants for the nested export, down
+// to maxGenerations, and counts the nodes marked as revisited.
+func buildNested(familyTree map[string]Person, name string, anonymizeLiving bool) (root *nestedNode, revisits int) {
+	visited := make(map[string]bool)
+	var build func(name string, depth int) *nestedNode
+	build = func(name string, depth int) *nestedNode {
+		node := &nestedNode{Name: exportName(familyTree[name], anonymizeLiving)}
+		if visited[name] {
+			node.Revisited = true
+			revisits++
+			return node
+		}
+		visited[name] = true
+		children := childrenOf(familyTree, name)
+		if len(children) > 0 && beyondGenerationLimit(depth+1) {
+			return node
+		}
+		for _, child := range children {
+			node.Children = append(node.Children, build(child, depth+1))
+		}
+		return node
+	}
+	return build(name, 0), revisits
+}
+
+// buildNestedParentsFirst is buildNested for --parents-first: everyone's full
+// node comes after the full nodes of all their parents in the export, for
+// importers that need parents before children. Each person is nested under
+// whichever of their parents was written last, and marked as revisited
+// under the others.
+func buildNestedParentsFirst(familyTree map[string]Person, name string, anonymizeLiving bool) (root *nestedNode, revisits int) {
+	var members []string
+	for member := range descendantDepths(familyTree, name) {
+		members = append(members, member)
+	}
+	members = append(members, name)
+	inExport := make(map[string]bool, len(members))
+	for _, member := range members {
+		inExport[member] = true
+	}
+	// Parents of name itself only appear through a cycle, and name is the
+	// top of the export whatever they say.
+	parents := func(member string) []string {
+		if member == name {
+			return nil
+		}
+		return parentsOf(familyTree, member)
+	}
+
+	nodes := make(map[string]*nestedNode)
+	above := make(map[string]string)
+	position := make(map[string]int)
+	// path returns the positions of member and the nodes above it, from the
+	// top down; comparing two paths compares places in the output.
+	path := func(member string) []int {
+		var positions []int
+		for ; member != name; member = above[member] {
+			positions = append([]int{position[member]}, positions...)
+		}
+		return positions
+	}
+	writtenAfter := func(a, b string) bool {
+		pathA, pathB := path(a), path(b)
+		for i := 0; i < len(pathA) && i < len(pathB); i++ {
+			if pathA[i] != pathB[i] {
+				return pathA[i] > pathB[i]
+			}
+		}
+		return len(pathA) > len(pathB)
+	}
+
+	order := parentsFirst(members, parents)
+	for i, member := range order {
+		node := &nestedNode{Name: exportName(familyTree[member], anonymizeLiving)}
+		nodes[member] = node
+		if member == name {
+			continue
+		}
+		home := ""
+		for _, parent := range parents(member) {
+			if nodes[parent] != nil && (home == "" || writtenAfter(parent, home)) {
+				home = parent
+			}
+		}
+		if home == "" {
+			// A cycle left no parent written yet; fall back to the top.
+			home = name
+		}
+		above[member], position[member] = home, i
+		nodes[home].Children = append(nodes[home].Children, node)
+	}
+
+	for _, member := range order {
+		for _, parent := range parents(member) {
+			if inExport[parent] && parent != above[member] {
+				stub := &nestedNode{Name: nodes[member].Name, Revisited: true}
+				nodes[parent].Children = append(nodes[parent].Children, stub)
+				revisits++
+			}
+		}
+	}
+	return nodes[name], revisits
+}
+
+// parentsFirst orders names so that everyone comes after all of their parents
+// among names, as returned by parents, taking the first name in sorted order
+// whenever there is a choice. People on a cycle of parent links cannot all
+// come after their parents; the cycle is broken at the first in sorted order
+// with a parent already placed.
+func parentsFirst(names []string, parents func(string) []string) []string {
+	sorted := append([]string(nil), names...)
+	sort.Strings(sorted)
+	included := make(map[string]bool, len(sorted))
+	for _, name := range sorted {
+		included[name] = true
+	}
+
+	waiting := make(map[string]int)
+	children := make(map[string][]string)
+	for _, name := range sorted {
+		for _, parent := range parents(name) {
+			if included[parent] && parent != name {
+				waiting[name]++
+				children[parent] = append(children[parent], name)
+			}
+		}
+	}
+
+	placed := make(map[string]bool, len(sorted))
+	order := make([]string, 0, len(sorted))
+	for len(order) < len(sorted) {
+		next := ""
+		for _, name := range sorted {
+			if !placed[name] && waiting[name] <= 0 {
+				next = name
+				break
+			}
+		}
+		if next == "" {
+			for _, name := range sorted {
+				if placed[name] {
+					continue
+				}
+				if next == "" {
+					next = name
+				}
+				if hasPlacedParent(parents(name), placed) {
+					next = name
+					break
+				}
+			}
+		}
+
+		placed[next] = true
+		order = append(order, next)
+		for _, child := range children[next] {
+			waiting[child]--
+		}
+	}
+	return order
+}
+
+// hasPlacedParent reports whether any of parents is in placed.
+func hasPlacedParent(parents []string, placed map[string]bool) bool {
+	for _, parent := range parents {
+		if placed[parent] {
+			return true
+		}
+	}
+	return false
+}
+
+// livingPlaceholder replaces the name of a living person in exports made
+// with --anonymize-living.
+const livingPlaceholder = "Living"
+
+// exportName returns the name to show for a person in an export. People
+// without a death date are presumed living and hidden when anonymizing.
+func exportName(person Person, anonymizeLiving bool) string {
+	if anonymizeLiving && person.Death == "" {
+		return livingPlaceholder
+	}
+	return person.Name
+}
+
+// edge is one directed relation: From is Type of To.
+type edge struct {
+	From string `json:"from"`
+	Type string `json:"type"`
+	To   string `json:"to"`
+}
+
+// relationEdges returns every relation that names its target, deduplicated
+// and sorted by holder, type and target.
+func relationEdges(familyTree map[string]Person) []edge {
+	seen := make(map[edge]bool)
+	var edges []edge
+	for _, name := range sortedNames(familyTree) {
+		for _, relation := range familyTree[name].Relations {
+			e := edge{From: name, Type: relation.Type, To: relation.Target}
+			if e.To == "" || seen[e] {
+				continue
+			}
+			seen[e] = true
+			edges = append(edges, e)
+		}
+	}
+	sort.SliceStable(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		if edges[i].Type != edges[j].Type {
+			return edges[i].Type < edges[j].Type
+		}
+		return edges[i].To < edges[j].To
+	})
+	return edges
+}
+
+func printEdges(asJSON, parentsFirstOrder bool) {
+	familyTree := loadFamilyTree()
+	edges := relationEdges(familyTree)
+	if parentsFirstOrder {
+		sortEdgesParentsFirst(familyTree, edges)
+	}
+
+	if asJSON {
+		if edges == nil {
+			edges = []edge{}
+		}
+		data, err := json.MarshalIndent(edges, "", "  ")
+		if err != nil {
+			fmt.Printf("Error encoding family tree data: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	for _, e := range edges {
+		fmt.Printf("%s\t%s\t%s\n", e.From, e.Type, e.To)
+	}
+}
+
+// sortEdgesParentsFirst reorders edges, stably, so that everyone's relations
+// come after those of their parents.
+func sortEdgesParentsFirst(familyTree map[string]Person, edges []edge) {
+	rank := make(map[string]int, len(familyTree))
+	for i, name := range parentsFirst(sortedNames(familyTree), func(name string) []string { return parentsOf(familyTree, name) }) {
+		rank[name] = i
+	}
+	sort.SliceStable(edges, func(i, j int) bool { return rank[edges[i].From] < rank[edges[j].From] })
+}
+
+// exportSubtree writes name, up generations of their ancestors, down
+// generations of their descendants and the spouses of all of them as a new
+// family tree file. Relations to anyone left out are dropped. With
+// parentsFirstOrder the people are written parents first instead of by name.
+func exportSubtree(name string, up, down int, output string, parentsFirstOrder bool) {
+	familyTree := loadFamilyTree()
+
+	if _, exists := familyTree[name]; !exists {
+		fmt.Printf("%s is not in the family tree.\n", name)
+		os.Exit(1)
+	}
+
+	included := map[string]bool{name: true}
+	for ancestor, generations := range ancestorDistances(familyTree, name) {
+		if generations <= up {
+			included[ancestor] = true
+		}
+	}
+	for descendant, generations := range descendantDepths(familyTree, name) {
+		if generations <= down {
+			included[descendant] = true
+		}
+	}
+	for member := range included {
+		for _, spouse := range spousesOf(familyTree, member) {
+			included[spouse] = true
+		}
+	}
+
+	subtree := make(map[string]Person, len(included))
+	for member := range included {
+		person := familyTree[member]
+		relations := []Relation{}
+		for _, relation := range person.Relations {
+			if relation.Target == "" || included[relation.Target] {
+				relations = append(relations, relation)
+			}
+		}
+		person.Relations = relations
+		subtree[member] = person
+	}
+
+	order := sortedNames(subtree)
+	if parentsFirstOrder {
+		order = parentsFirst(order, func(member string) []string { return parentsOf(subtree, member) })
+	}
+	data, err := encodeFamilyTreeInOrder(subtree, order)
+	if err != nil {
+		fmt.Printf("Error encoding family tree data: %v\n", err)
+		os.Exit(1)
+	}
+
+	if output == "" {
+		fmt.Println(string(data))
+		return
+	}
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		fmt.Printf("Error writing %s: %v\n", output, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Exported %d %s around %s to %s.\n", len(subtree), plural(len(subtree), "person"), name, output)
+}
+
+// kinshipEntry is one person in the labels export.
+type kinshipEntry struct {
+	Name       string `json:"name"`
+	Label      string `json:"label,omitempty"`
+	Generation *int   `json:"generation,omitempty"`
+}
+
+// exportLabels writes, for everyone in the tree, their relationship to anchor
+// and their generation relative to anchor (0 for the anchor's generation,
+// negative for older ones). The output is sorted by name.
+func exportLabels(anchor, output string) {
+	familyTree := loadFamilyTree()
+
+	if _, exists := familyTree[anchor]; !exists {
+		fmt.Printf("%s is not in the family tree.\n", anchor)
+		os.Exit(1)
+	}
+
+	entries := []kinshipEntry{}
+	for _, name := range sortedNames(familyTree) {
+		entry := kinshipEntry{Name: name, Label: kinshipLabel(familyTree, anchor, name)}
+		if generation, ok := relativeGeneration(familyTree, anchor, name); ok {
+			entry.Generation = &generation
+		}
+		entries = append(entries, entry)
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		fmt.Printf("Error encoding labels: %v\n", err)
+		os.Exit(1)
+	}
+
+	if output == "" {
+		fmt.Println(string(data))
+		return
+	}
+	if err := os.WriteFile(output, append(data, '\n'), 0644); err != nil {
+		fmt.Printf("Error writing %s: %v\n", output, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Exported relationship labels for %d %s to %s.\n", len(entries), plural(len(entries), "person"), output)
+}
+
+// anonymize writes a copy of the family tree with everyone renamed Person1,
+// Person2 and so on, in alphabetical order of their real names, and with
+// dates, tags, sources and photos removed. Relations and recorded genders are
+// kept, so the copy has the same structure and still reproduces problems.
+func anonymize(output string) {
+	familyTree := loadFamilyTree()
+
+	alias := make(map[string]string)
+	for _, name := range sortedNames(familyTree) {
+		alias[name] = fmt.Sprintf("Person%d", len(alias)+1)
+	}
+	// Relations to people missing from the tree still need a stand-in name.
+	for _, name := range sortedNames(familyTree) {
+		for _, relation := range familyTree[name].Relations {
+			if _, named := alias[relation.Target]; relation.Target != "" && !named {
+				alias[relation.Target] = fmt.Sprintf("Person%d", len(alias)+1)
+			}
+		}
+	}
+
+	anonymous := make(map[string]Person, len(familyTree))
+	for name, person := range familyTree {
+		relations := []Relation{}
+		for _, relation := range person.Relations {
+			relations = append(relations, Relation{Type: relation.Type, Target: alias[relation.Target]})
+		}
+		anonymous[alias[name]] = Person{Name: alias[name], Gender: person.Gender, Relations: relations}
+	}
+
+	data, err := encodeFamilyTree(anonymous)
+	if err != nil {
+		fmt.Printf("Error encoding family tree data: %v\n", err)
+		os.Exit(1)
+	}
+
+	if output == "" {
+		fmt.Println(string(data))
+		return
+	}
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		fmt.Printf("Error writing %s: %v\n", output, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Wrote an anonymized copy of %d %s to %s.\n", len(anonymous), plural(len(anonymous), "person"), output)
+}
+
+// mermaidEscaper makes a name safe inside a quoted Mermaid node label.
+var mermaidEscaper = strings.NewReplacer("#", "#35;", `"`, "#quot;", "<", "#lt;", ">", "#gt;")
+
+// exportMermaid writes the tree as a Mermaid "graph TD" diagram: a node per
+// person and an edge from each parent to each child, labelled with the
+// child's relation type (son, daughter or child) when recorded, or else the
+// parent's.
+func exportMermaid(output string) {
+	familyTree := loadFamilyTree()
+
+	var b strings.Builder
+	b.WriteString("graph TD\n")
+	id := make(map[string]string)
+	for _, name := range sortedNames(familyTree) {
+		id[name] = fmt.Sprintf("p%d", len(id)+1)
+		fmt.Fprintf(&b, "    %s[\"%s\"]\n", id[name], mermaidEscaper.Replace(name))
+	}
+	for _, parent := range sortedNames(familyTree) {
+		for _, child := range childrenOf(familyTree, parent) {
+			fmt.Fprintf(&b, "    %s -->|%s| %s\n", id[parent], parentChildLabel(familyTree, parent, child), id[child])
+		}
+	}
+
+	if output == "" {
+		fmt.Print(b.String())
+		return
+	}
+	if err := os.WriteFile(output, []byte(b.String()), 0644); err != nil {
+		fmt.Printf("Error writing %s: %v\n", output, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Exported %d %s to %s.\n", len(familyTree), plural(len(familyTree), "person"), output)
+}
+
+// parentChildLabel names the link between parent and child by the type the
+// child holds, falling back to the type the parent holds.
+func parentChildLabel(familyTree map[string]Person, parent, child string) string {
+	for _, relation := range familyTree[child].Relations {
+		if relation.Target == parent && childTypes[relation.Type] {
+			return relation.Type
+		}
+	}
+	for _, relation := range familyTree[parent].Relations {
+		if relation.Target == child && parentTypes[relation.Type] {
+			return relation.Type
+		}
+	}
+	return "child"
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// crossedTree is a small family whose alphabetical order is not parents
+// first: Zara and Mohan are Amit's parents, Amit and Kavya are Bela's, and
+// Bela is Chetan's mother.
+func crossedTree(t *testing.T) map[string]Person {
+	t.Helper()
+	familyTree := map[string]Person{}
+	for _, name := range []string{"Zara", "Mohan", "Amit", "Kavya", "Bela", "Chetan"} {
+		familyTree[name] = Person{Name: name}
+	}
+	for _, link := range [][3]string{
+		{"Zara", "mother", "Amit"},
+		{"Mohan", "father", "Amit"},
+		{"Amit", "father", "Bela"},
+		{"Kavya", "mother", "Bela"},
+		{"Bela", "mother", "Chetan"},
+	} {
+		if err := addRelation(familyTree, link[0], link[2], link[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return familyTree
+}
+
+// checkParentsFirst fails the test unless order lists each of names once and
+// everyone after all of their parents among names.
+func checkParentsFirst(t *testing.T, familyTree map[string]Person, names, order []string) {
+	t.Helper()
+	if len(order) != len(names) {
+		t.Fatalf("order %v has %d people, want %d", order, len(order), len(names))
+	}
+	position := make(map[string]int)
+	for i, name := range order {
+		if _, repeated := position[name]; repeated {
+			t.Fatalf("%s appears twice in %v", name, order)
+		}
+		position[name] = i
+	}
+	for _, name := range names {
+		for _, parent := range parentsOf(familyTree, name) {
+			if p, included := position[parent]; included && p > position[name] {
+				t.Errorf("%s comes after their child %s in %v", parent, name, order)
+			}
+		}
+	}
+}
+
+func TestParentsFirstIsTopological(t *testing.T) {
+	familyTree := crossedTree(t)
+	names := sortedNames(familyTree)
+	order := parentsFirst(names, func(name string) []string { return parentsOf(familyTree, name) })
+	checkParentsFirst(t, familyTree, names, order)
+
+	want := "Kavya Mohan Zara Amit Bela Chetan"
+	if got := strings.Join(order, " "); got != want {
+		t.Errorf("parentsFirst = %s, want %s", got, want)
+	}
+}
+
+func TestParentsFirstBreaksCycles(t *testing.T) {
+	familyTree := map[string]Person{}
+	for _, name := range []string{"A", "B", "C"} {
+		familyTree[name] = Person{Name: name}
+	}
+	// A and B are each other's parent, which only malformed data can say.
+	for _, link := range [][3]string{{"A", "father", "B"}, {"B", "father", "A"}, {"B", "father", "C"}} {
+		if err := addRelation(familyTree, link[0], link[2], link[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	order := parentsFirst(sortedNames(familyTree), func(name string) []string { return parentsOf(familyTree, name) })
+	if got := strings.Join(order, " "); got != "A B C" {
+		t.Errorf("parentsFirst = %s, want A B C", got)
+	}
+}
+
+func TestEncodeFamilyTreeInOrder(t *testing.T) {
+	familyTree := crossedTree(t)
+
+	sorted, err := encodeFamilyTreeInOrder(familyTree, sortedNames(familyTree))
+	if err != nil {
+		t.Fatal(err)
+	}
+	canonical, err := encodeFamilyTree(familyTree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sorted, canonical) {
+		t.Errorf("sorted order encoded differently from encodeFamilyTree:\n%s\n---\n%s", sorted, canonical)
+	}
+
+	order := parentsFirst(sortedNames(familyTree), func(name string) []string { return parentsOf(familyTree, name) })
+	data, err := encodeFamilyTreeInOrder(familyTree, order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := decodeFamilyTree(data)
+	if err != nil {
+		t.Fatalf("the parents-first file does not decode: %v\n%s", err, data)
+	}
+	if len(decoded) != len(familyTree) {
+		t.Errorf("decoded %d people, want %d", len(decoded), len(familyTree))
+	}
+	var written []string
+	for i := 0; i < len(data); {
+		next := bytes.Index(data[i:], []byte("\n    \""))
+		if next < 0 {
+			break
+		}
+		start := i + next + len("\n    \"")
+		end := start + bytes.IndexByte(data[start:], '"')
+		written = append(written, string(data[start:end]))
+		i = end
+	}
+	checkParentsFirst(t, familyTree, sortedNames(familyTree), written)
+}
+
+func TestBuildNestedParentsFirst(t *testing.T) {
+	familyTree := crossedTree(t)
+	// Give Mohan a second line down to Bela, through Kavya, so Bela has two
+	// parents inside the export.
+	if err := addRelation(familyTree, "Mohan", "Kavya", "father"); err != nil {
+		t.Fatal(err)
+	}
+
+	root, revisits := buildNestedParentsFirst(familyTree, "Mohan", false)
+	if revisits != 1 {
+		t.Errorf("revisits = %d, want 1 for Bela under her second parent", revisits)
+	}
+
+	// The full nodes, in the order they are written, must put parents first.
+	var written []string
+	var walk func(node *nestedNode)
+	walk = func(node *nestedNode) {
+		if node.Revisited {
+			return
+		}
+		written = append(written, node.Name)
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+	walk(root)
+	names := []string{"Mohan", "Amit", "Kavya", "Bela", "Chetan"}
+	checkParentsFirst(t, familyTree, names, written)
+}
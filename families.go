@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// rootScope is the person named with --root. When set, loadFamilyTree keeps
+// only the family that person belongs to.
+var rootScope string
+
+// families splits the tree into its separate families: groups of people
+// linked to each other by some chain of relations, largest first. Each
+// family is sorted by name.
+func families(familyTree map[string]Person) [][]string {
+	seen := make(map[string]bool)
+	var groups [][]string
+	for _, name := range sortedNames(familyTree) {
+		if seen[name] {
+			continue
+		}
+		var group []string
+		for member := range distancesFrom(familyTree, name) {
+			seen[member] = true
+			group = append(group, member)
+		}
+		sort.Strings(group)
+		groups = append(groups, group)
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return len(groups[i]) > len(groups[j]) })
+	return groups
+}
+
+// scopeToFamily returns just the family of root: everyone linked to them by
+// some chain of relations.
+func scopeToFamily(familyTree map[string]Person, root string) map[string]Person {
+	if _, exists := familyTree[root]; !exists {
+		fmt.Printf("%s is not in the family tree.\n", root)
+		os.Exit(1)
+	}
+	scoped := make(map[string]Person)
+	for name := range distancesFrom(familyTree, root) {
+		scoped[name] = familyTree[name]
+	}
+	return scoped
+}
+
+// noteSeparateFamilies tells the user when a whole-tree report covers
+// several unrelated families at once.
+func noteSeparateFamilies(familyTree map[string]Person) {
+	if rootScope != "" {
+		return
+	}
+	if n := len(families(familyTree)); n > 1 {
+		fmt.Printf("Note: %d separate families found; use --root <name> to scope.\n", n)
+	}
+}
+
+// familyRoots returns the sorted people of a family with no recorded
+// parents who head a line of descent, or the lone member of a family of one.
+func familyRoots(familyTree map[string]Person, family []string) []string {
+	if len(family) == 1 {
+		return family
+	}
+	var roots []string
+	for _, name := range family {
+		if len(parentsOf(familyTree, name)) == 0 && len(childrenOf(familyTree, name)) > 0 {
+			roots = append(roots, name)
+		}
+	}
+	return roots
+}
+
+// printRoots lists each separate family in the tree with its size and the
+// people at the top of it.
+func printRoots() {
+	familyTree := loadFamilyTree()
+
+	groups := families(familyTree)
+	if len(groups) == 0 {
+		fmt.Println("The family tree is empty.")
+		return
+	}
+
+	for i, family := range groups {
+		roots := familyRoots(familyTree, family)
+		summary := "no parent links recorded"
+		if len(roots) > 0 {
+			summary = "roots: " + strings.Join(roots, ", ")
+		}
+		fmt.Printf("Family %d (%d %s): %s\n", i+1, len(family), plural(len(family), "person"), summary)
+	}
+	if len(groups) > 1 {
+		fmt.Printf("\n%d separate families found; use --root <name> to scope other commands to one.\n", len(groups))
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// A filter expression compares person fields with values and combines the
+// comparisons with AND, OR and parentheses, e.g.
+//
+//	gender=female AND (generation>2 OR tag=immigrant)
+//
+// AND binds tighter than OR. Keywords and text comparisons ignore case.
+
+// filterFields are the fields a filter expression can test, with how to read
+// each from a person. ok is false when the value is not recorded, which
+// makes every comparison with it false.
+var filterFields = map[string]func(facts personFacts) (value string, ok bool){
+	"name":    func(f personFacts) (string, bool) { return f.name, true },
+	"surname": func(f personFacts) (string, bool) { return surname(f.name), true },
+	"given": func(f personFacts) (string, bool) {
+		given, _ := splitName(f.name)
+		return given, true
+	},
+	"gender": func(f personFacts) (string, bool) { return genderOf(f.familyTree, f.name), true },
+	"generation": func(f personFacts) (string, bool) {
+		generation, ok := f.generations[f.name]
+		return strconv.Itoa(generation), ok
+	},
+	"birth": func(f personFacts) (string, bool) {
+		date, ok := personDate(f.familyTree[f.name].Birth)
+		return strconv.Itoa(date.Year), ok
+	},
+	"death": func(f personFacts) (string, bool) {
+		date, ok := personDate(f.familyTree[f.name].Death)
+		return strconv.Itoa(date.Year), ok
+	},
+	"living": func(f personFacts) (string, bool) {
+		return strconv.FormatBool(f.familyTree[f.name].Death == ""), true
+	},
+	"children": func(f personFacts) (string, bool) {
+		return strconv.Itoa(len(childrenOf(f.familyTree, f.name))), true
+	},
+	"spouses": func(f personFacts) (string, bool) {
+		return strconv.Itoa(len(spousesOf(f.familyTree, f.name))), true
+	},
+}
+
+// personFacts is what a filter is evaluated against: one person, with the
+// tree and the generation numbers worked out once for the whole tree.
+type personFacts struct {
+	familyTree  map[string]Person
+	generations map[string]int
+	name        string
+}
+
+type filter func(facts personFacts) bool
+
+// parseFilter compiles a filter expression.
+func parseFilter(expression string) (filter, error) {
+	tokens, err := tokenizeFilter(expression)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected '%s'", p.tokens[p.pos])
+	}
+	return f, nil
+}
+
+// tokenizeFilter splits an expression into words, quoted values, operators
+// and parentheses.
+func tokenizeFilter(expression string) ([]string, error) {
+	var tokens []string
+	runes := []rune(expression)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, string(r))
+			i++
+		case strings.ContainsRune("=!<>", r):
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				tokens = append(tokens, string(runes[i:i+2]))
+				i += 2
+			} else if r == '!' {
+				return nil, fmt.Errorf("'!' must be followed by '='")
+			} else {
+				tokens = append(tokens, string(r))
+				i++
+			}
+		case r == '"' || r == '\'':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("unterminated quoted value")
+			}
+			// Keep the opening quote so a quoted value is never taken for a keyword.
+			tokens = append(tokens, string(runes[i:end]))
+			i = end + 1
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune("()=!<>\"'", runes[i]) {
+				i++
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		}
+	}
+	return tokens, nil
+}
+
+type filterParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *filterParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *filterParser) next() string {
+	token := p.peek()
+	p.pos++
+	return token
+}
+
+func (p *filterParser) parseOr() (filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(p.peek(), "or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(facts personFacts) bool { return l(facts) || right(facts) }
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filter, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(p.peek(), "and") {
+		p.next()
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(facts personFacts) bool { return l(facts) && right(facts) }
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseTerm() (filter, error) {
+	if p.peek() == "(" {
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing ')'")
+		}
+		return inner, nil
+	}
+
+	field := strings.ToLower(p.next())
+	if field == "" {
+		return nil, fmt.Errorf("expression ends too early")
+	}
+	operator := p.next()
+	value := p.next()
+	if value == "" || value == "(" || value == ")" {
+		return nil, fmt.Errorf("missing value after '%s %s'", field, operator)
+	}
+	value = strings.TrimLeft(value, "\"'")
+
+	if field == "tag" {
+		if operator != "=" && operator != "!=" {
+			return nil, fmt.Errorf("tag can only be compared with = or !=")
+		}
+		want := operator == "="
+		return func(facts personFacts) bool {
+			return hasTag(facts.familyTree[facts.name], value) == want
+		}, nil
+	}
+
+	read, known := filterFields[field]
+	if !known {
+		return nil, fmt.Errorf("unknown field '%s'", field)
+	}
+	compare, err := comparison(operator)
+	if err != nil {
+		return nil, err
+	}
+	return func(facts personFacts) bool {
+		actual, ok := read(facts)
+		return ok && compare(actual, value)
+	}, nil
+}
+
+// comparison returns the test for operator. Values that are both numbers
+// compare as numbers, anything else as text ignoring case.
+func comparison(operator string) (func(actual, value string) bool, error) {
+	var test func(order int) bool
+	switch operator {
+	case "=":
+		test = func(order int) bool { return order == 0 }
+	case "!=":
+		test = func(order int) bool { return order != 0 }
+	case "<":
+		test = func(order int) bool { return order < 0 }
+	case "<=":
+		test = func(order int) bool { return order <= 0 }
+	case ">":
+		test = func(order int) bool { return order > 0 }
+	case ">=":
+		test = func(order int) bool { return order >= 0 }
+	default:
+		return nil, fmt.Errorf("expected a comparison such as = or > instead of '%s'", operator)
+	}
+	return func(actual, value string) bool {
+		a, errA := strconv.ParseFloat(actual, 64)
+		b, errB := strconv.ParseFloat(value, 64)
+		if errA == nil && errB == nil {
+			switch {
+			case a < b:
+				return test(-1)
+			case a > b:
+				return test(1)
+			}
+			return test(0)
+		}
+		return test(strings.Compare(strings.ToLower(actual), strings.ToLower(value)))
+	}, nil
+}
+
+// countPeople counts the people matching the filter expression where, or
+// everyone when it is empty, listing their names when list is set.
+func countPeople(where string, list bool) {
+	match := filter(func(personFacts) bool { return true })
+	if where != "" {
+		f, err := parseFilter(where)
+		if err != nil {
+			fmt.Printf("Invalid filter: %v.\n", err)
+			os.Exit(1)
+		}
+		match = f
+	}
+
+	familyTree := loadFamilyTree()
+	facts := personFacts{familyTree: familyTree, generations: generationNumbers(familyTree)}
+	var names []string
+	for _, name := range sortedNames(familyTree) {
+		facts.name = name
+		if match(facts) {
+			names = append(names, name)
+		}
+	}
+
+	fmt.Printf("%d %s.\n", len(names), plural(len(names), "person"))
+	if list {
+		for _, name := range names {
+			fmt.Printf("  %s\n", name)
+		}
+	}
+	noteSeparateFamilies(familyTree)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// impliedGenders returns the genders implied by the relation types name
+// holds, e.g. "male" for a father or husband. More than one means the
+// relations contradict each other.
+func impliedGenders(familyTree map[string]Person, name string) []string {
+	implied := make(map[string][]string)
+	for _, relation := range familyTree[name].Relations {
+		switch {
+		case maleTypes[relation.Type]:
+			implied["male"] = append(implied["male"], relation.Type)
+		case femaleTypes[relation.Type]:
+			implied["female"] = append(implied["female"], relation.Type)
+		}
+	}
+	genders := make([]string, 0, len(implied))
+	for gender := range implied {
+		genders = append(genders, gender)
+	}
+	sort.Strings(genders)
+	return genders
+}
+
+// inferGender records a gender for everyone without one whose relations
+// imply it, and reports people whose relations contradict each other or
+// their recorded gender. With dryRun nothing is saved.
+func inferGender(dryRun bool) {
+	familyTree := loadFamilyTree()
+
+	set, conflicts := 0, 0
+	for _, name := range sortedNames(familyTree) {
+		person := familyTree[name]
+		implied := impliedGenders(familyTree, name)
+		switch {
+		case len(implied) > 1:
+			fmt.Printf("Conflict: %s's relations imply both %s.\n", name, strings.Join(implied, " and "))
+			conflicts++
+		case len(implied) == 0:
+		case person.Gender == "" || person.Gender == "unknown":
+			fmt.Printf("%s: %s\n", name, implied[0])
+			// In a batch every command shares one map, so a dry run must
+			// leave it alone or a later command would save its changes.
+			if !dryRun {
+				person.Gender = implied[0]
+				familyTree[name] = person
+			}
+			set++
+		case person.Gender != implied[0]:
+			fmt.Printf("Conflict: %s is recorded as %s, but their relations imply %s.\n", name, person.Gender, implied[0])
+			conflicts++
+		}
+	}
+
+	if set == 0 {
+		fmt.Println("No genders to infer.")
+	} else if dryRun {
+		fmt.Printf("\nWould set %d %s. Run without --dry-run to save them.\n", set, plural(set, "gender"))
+	} else {
+		saveFamilyTree(familyTree)
+		fmt.Printf("\nSet %d %s.\n", set, plural(set, "gender"))
+	}
+	if conflicts > 0 {
+		fmt.Printf("%d %s left for you to resolve.\n", conflicts, plural(conflicts, "conflict"))
+	}
+}
+
+// setGender records name's gender as male or female, or clears it with
+// "unknown" so it is inferred from their relations again. A gender that
+// contradicts their relations is saved with a warning.
+func setGender(name, gender string) {
+	gender = strings.ToLower(gender)
+	if gender != "male" && gender != "female" && gender != "unknown" {
+		fmt.Println("The gender must be male, female or unknown.")
+		os.Exit(1)
+	}
+
+	familyTree := loadFamilyTree()
+
+	person, exists := familyTree[name]
+	if !exists {
+		fmt.Printf("%s is not in the family tree.\n", name)
+		os.Exit(1)
+	}
+
+	if implied := impliedGenders(familyTree, name); gender != "unknown" && len(implied) == 1 && implied[0] != gender {
+		fmt.Printf("Warning: %s's relations imply %s.\n", name, implied[0])
+	}
+
+	person.Gender = gender
+	if gender == "unknown" {
+		person.Gender = ""
+	}
+	familyTree[name] = person
+	saveFamilyTree(familyTree)
+
+	fmt.Printf("Set %s's gender to %s.\n", name, gender)
+}
@@ -2,4 +2,11 @@ module family-tree
 
 go 1.20
 
-require gonum.org/v1/gonum v0.14.0
+require (
+	golang.org/x/crypto v0.17.0
+	golang.org/x/term v0.15.0
+	golang.org/x/text v0.14.0
+	gonum.org/v1/gonum v0.14.0
+)
+
+require golang.org/x/sys v0.15.0 // indirect
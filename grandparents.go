@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// grandparentsInRole returns the sorted, distinct people found by applying
+// parentOf to each of name's parents, on both sides. parentOf is fatherOf or
+// motherOf, the lookups behind findFather and findMother; a parent for whom
+// it finds no one is skipped.
+func grandparentsInRole(familyTree map[string]Person, name string, parentOf func(map[string]Person, string) string) []string {
+	found := make(map[string]bool)
+	for _, parent := range parentsOf(familyTree, name) {
+		if grandparent := parentOf(familyTree, parent); grandparent != "" && grandparent != name {
+			found[grandparent] = true
+		}
+	}
+	grandparents := make([]string, 0, len(found))
+	for grandparent := range found {
+		grandparents = append(grandparents, grandparent)
+	}
+	sort.Strings(grandparents)
+	return grandparents
+}
+
+// printGrandparents prints each of name's grandfathers or grandmothers, as
+// role says, on its own line.
+func printGrandparents(name, role string) {
+	familyTree := loadFamilyTree()
+
+	if _, exists := familyTree[name]; !exists {
+		fmt.Printf("%s is not in the family tree.\n", name)
+		os.Exit(1)
+	}
+
+	parentOf := motherOf
+	if role == "grandfather" {
+		parentOf = fatherOf
+	}
+	grandparents := grandparentsInRole(familyTree, name, parentOf)
+
+	if len(grandparents) == 0 {
+		fmt.Printf("No %s found in the family tree.\n", role)
+		return
+	}
+	for _, grandparent := range grandparents {
+		fmt.Printf("%s of %s is %s.\n", strings.ToUpper(role[:1])+role[1:], name, grandparent)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// healthReport summarises the data quality of a family tree.
+type healthReport struct {
+	People          int     `json:"people"`
+	Relations       int     `json:"relations"`
+	KnownGender     float64 `json:"knownGenderPercent"`
+	WithBirthDate   float64 `json:"birthDatePercent"`
+	Dangling        int     `json:"danglingReferences"`
+	ReciprocityGaps int     `json:"reciprocityGaps"`
+	Orphans         int     `json:"orphans"`
+	Score           float64 `json:"score"`
+}
+
+// checkHealth computes the health report. The score is the average of five
+// percentages: people with a known gender, people with a birth date,
+// relations that are not dangling, relations that are not reciprocity gaps,
+// and people who are not orphans.
+func checkHealth(familyTree map[string]Person) healthReport {
+	report := healthReport{People: len(familyTree)}
+	if report.People == 0 {
+		report.Score = 100
+		return report
+	}
+
+	gendered, dated := 0, 0
+	for name, person := range familyTree {
+		report.Relations += len(person.Relations)
+		if genderOf(familyTree, name) != "unknown" {
+			gendered++
+		}
+		if person.Birth != "" {
+			dated++
+		}
+		if isOrphan(familyTree, name) {
+			report.Orphans++
+		}
+	}
+	report.KnownGender = percent(gendered, report.People)
+	report.WithBirthDate = percent(dated, report.People)
+	report.Dangling = danglingRelations(familyTree)
+	report.ReciprocityGaps = len(reciprocityGaps(familyTree))
+
+	linked, reciprocated := 100.0, 100.0
+	if report.Relations > 0 {
+		linked = 100 - percent(report.Dangling, report.Relations)
+		reciprocated = 100 - percent(report.ReciprocityGaps, report.Relations)
+	}
+	connected := 100 - percent(report.Orphans, report.People)
+	report.Score = (report.KnownGender + report.WithBirthDate + linked + reciprocated + connected) / 5
+	return report
+}
+
+func percent(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return 100 * float64(part) / float64(whole)
+}
+
+func printHealth(asJSON bool) {
+	report := checkHealth(loadFamilyTree())
+
+	if asJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			fmt.Printf("Error encoding health report: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	fmt.Printf("Data quality score: %.0f/100\n\n", report.Score)
+	fmt.Printf("People:               %d\n", report.People)
+	fmt.Printf("Relations:            %d\n", report.Relations)
+	fmt.Printf("Known gender:         %.0f%%\n", report.KnownGender)
+	fmt.Printf("With birth date:      %.0f%%\n", report.WithBirthDate)
+	fmt.Printf("Dangling references:  %d\n", report.Dangling)
+	fmt.Printf("Reciprocity gaps:     %d\n", report.ReciprocityGaps)
+	fmt.Printf("Orphans:              %d\n", report.Orphans)
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// importOutline reads an indented outline in which each line names a person
+// and a line indented further than the one above it is that person's child:
+//
+//	Ravi
+//	  KK
+//	    Amit
+//	  Raj
+//
+// People not yet in the tree are added, and each child is linked to the
+// nearest less indented line above it. Blank lines and lines starting with
+// "#" are skipped. A name used twice refers to the same person.
+func importOutline(data []byte) {
+	familyTree := loadFamilyTree()
+
+	type entry struct {
+		indent int
+		name   string
+	}
+	var stack []entry
+	added, linked := 0, 0
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := scanner.Text()
+		name := strings.TrimSpace(line)
+		if name == "" || strings.HasPrefix(name, "#") {
+			continue
+		}
+		indent := indentation(line)
+
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+
+		if _, exists := familyTree[name]; !exists {
+			familyTree[name] = Person{Name: name, Relations: []Relation{}}
+			added++
+		}
+		if len(stack) > 0 {
+			parent := stack[len(stack)-1].name
+			if parent == name {
+				fmt.Printf("Line %d: %s cannot be their own child. Nothing was imported.\n", lineNumber, name)
+				os.Exit(1)
+			}
+			if !contains(childrenOf(familyTree, parent), name) {
+				addRelation(familyTree, name, parent, "child")
+				linked++
+			}
+			fmt.Printf("%s%s (child of %s)\n", strings.Repeat("  ", len(stack)), name, parent)
+		} else {
+			fmt.Println(name)
+		}
+		stack = append(stack, entry{indent, name})
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Printf("Error reading outline: %v\n", err)
+		os.Exit(1)
+	}
+
+	saveFamilyTree(familyTree)
+	fmt.Printf("\nAdded %d %s and %d parent-child %s.\n", added, plural(added, "person"), linked, plural(linked, "link"))
+}
+
+// indentation measures a line's leading whitespace, counting a tab as four
+// spaces.
+func indentation(line string) int {
+	width := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			width++
+		case '\t':
+			width += 4
+		default:
+			return width
+		}
+	}
+	return width
+}
+
+// importers are the formats import can read, by the name detectFormat gives.
+var importers = map[string]func(data []byte){
+	"outline": importOutline,
+}
+
+// importFile imports path in the given format, or in the format detectFormat
+// finds when format is empty. The file is first transcoded to UTF-8 from
+// encoding; see inputEncodings.
+func importFile(path, format, encoding string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Printf("Error reading import file: %v\n", err)
+		os.Exit(1)
+	}
+	data, converted, err := transcode(data, encoding)
+	if err != nil {
+		fmt.Printf("Error reading %s: %v.\n", path, err)
+		os.Exit(1)
+	}
+	if converted {
+		fmt.Printf("Transcoded %s from %s to UTF-8.\n", path, strings.ToLower(encoding))
+	}
+
+	supported := make([]string, 0, len(importers))
+	for name := range importers {
+		supported = append(supported, name)
+	}
+	sort.Strings(supported)
+
+	if format == "" {
+		format = detectFormat(data)
+		if format == "" {
+			fmt.Printf("Could not tell the format of %s. Supported formats: %s.\n", path, strings.Join(supported, ", "))
+			os.Exit(1)
+		}
+	}
+	importer, ok := importers[format]
+	if !ok {
+		if format != "outline" {
+			format = strings.ToUpper(format)
+		}
+		fmt.Printf("%s is a %s file, which cannot be imported yet. Supported formats: %s.\n", path, format, strings.Join(supported, ", "))
+		os.Exit(1)
+	}
+	importer(data)
+}
+
+// detectFormat recognises an import file by its contents: a GEDCOM header
+// ("0 HEAD"), a JSON document, a CSV header row, or otherwise lines of names
+// forming an outline. It returns "gedcom", "json", "csv", "outline", or ""
+// when the data is empty.
+func detectFormat(data []byte) string {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	text := strings.TrimSpace(string(data))
+	if text == "" {
+		return ""
+	}
+	if strings.HasPrefix(text, "0 HEAD") {
+		return "gedcom"
+	}
+	if text[0] == '{' || text[0] == '[' {
+		return "json"
+	}
+	firstLine := strings.SplitN(text, "\n", 2)[0]
+	if strings.Count(firstLine, ",") >= 1 && !strings.HasPrefix(firstLine, "#") {
+		return "csv"
+	}
+	return "outline"
+}
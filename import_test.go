@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"gedcom", "0 HEAD\n1 SOUR PAF\n0 @I1@ INDI\n", "gedcom"},
+		{"gedcom with BOM", "\xef\xbb\xbf0 HEAD\n1 CHAR UTF-8\n", "gedcom"},
+		{"gedcom after blank lines", "\n\n0 HEAD\n", "gedcom"},
+		{"json object", `{"schemaVersion": 2, "people": {}}`, "json"},
+		{"json array", `[{"name": "Amit"}]`, "json"},
+		{"csv", "name,father,mother\nAmit,KK,Sita\n", "csv"},
+		{"outline", "Ravi\n  KK\n    Amit\n", "outline"},
+		{"outline with comment", "# Ravi, KK and Amit\nRavi\n  KK\n", "outline"},
+		{"single name", "Amit", "outline"},
+		{"empty", "", ""},
+		{"only whitespace", " \n\t\n", ""},
+		{"only BOM", "\xef\xbb\xbf", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectFormat([]byte(tt.data)); got != tt.want {
+				t.Errorf("detectFormat(%q) = %q, want %q", tt.data, got, tt.want)
+			}
+		})
+	}
+}
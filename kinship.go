@@ -0,0 +1,365 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ancestorDistances returns every ancestor of name with the number of
+// generations to the nearest path up to them. name itself is at 0.
+func ancestorDistances(familyTree map[string]Person, name string) map[string]int {
+	distance := map[string]int{name: 0}
+	queue := []string{name}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, parent := range parentsOf(familyTree, current) {
+			if beyondGenerationLimit(distance[current] + 1) {
+				break
+			}
+			if _, seen := distance[parent]; !seen {
+				distance[parent] = distance[current] + 1
+				queue = append(queue, parent)
+			}
+		}
+	}
+	return distance
+}
+
+// commonAncestor finds the nearest shared ancestor of a and b (either may be
+// the other's ancestor). up is the number of generations from a to it and
+// down the number from b. ok is false when they are not blood relatives.
+func commonAncestor(familyTree map[string]Person, a, b string) (ancestor string, up, down int, ok bool) {
+	fromA := ancestorDistances(familyTree, a)
+	fromB := ancestorDistances(familyTree, b)
+
+	var shared []string
+	for name := range fromA {
+		if _, found := fromB[name]; found {
+			shared = append(shared, name)
+		}
+	}
+	if len(shared) == 0 {
+		return "", 0, 0, false
+	}
+
+	sort.Strings(shared)
+	best := shared[0]
+	for _, name := range shared[1:] {
+		if fromA[name]+fromB[name] < fromA[best]+fromB[best] {
+			best = name
+		}
+	}
+	return best, fromA[best], fromB[best], true
+}
+
+// kinshipLabel describes what other is to name, e.g. "grandfather",
+// "first cousin once removed" or "sister-in-law". It returns "" when no
+// relationship can be worked out.
+func kinshipLabel(familyTree map[string]Person, name, other string) string {
+	if name == other {
+		return "self"
+	}
+
+	gender := genderOf(familyTree, other)
+	if _, up, down, ok := commonAncestor(familyTree, name, other); ok {
+		return bloodLabel(up, down, gender)
+	}
+
+	// Not blood relatives: try a direct link, then the spouse's family.
+	for _, relation := range familyTree[other].Relations {
+		if relation.Target == name {
+			return relation.Type
+		}
+	}
+	for _, spouse := range spousesOf(familyTree, name) {
+		if spouse == other {
+			return gendered(gender, "husband", "wife", "spouse")
+		}
+		if _, up, down, ok := commonAncestor(familyTree, spouse, other); ok {
+			switch {
+			case up == 1 && down == 0:
+				return gendered(gender, "father", "mother", "parent") + "-in-law"
+			case up == 1 && down == 1:
+				return gendered(gender, "brother", "sister", "sibling") + "-in-law"
+			case up == 0 && down == 1:
+				return gendered(gender, "son", "daughter", "child") + "-in-law"
+			}
+			return "relative by marriage"
+		}
+	}
+	for _, spouse := range spousesOf(familyTree, other) {
+		if _, up, down, ok := commonAncestor(familyTree, name, spouse); ok {
+			switch {
+			case up == 0 && down == 1:
+				return gendered(gender, "son", "daughter", "child") + "-in-law"
+			case up == 1 && down == 1:
+				return gendered(gender, "brother", "sister", "sibling") + "-in-law"
+			}
+			return "relative by marriage"
+		}
+	}
+	return ""
+}
+
+// bloodLabel names a blood relative reached by going up generations from the
+// first person to the common ancestor and down generations to the relative.
+func bloodLabel(up, down int, gender string) string {
+	switch {
+	case up == 0 && down == 0:
+		return "self"
+	case up == 0:
+		return greats(down, gendered(gender, "son", "daughter", "child"), gendered(gender, "grandson", "granddaughter", "grandchild"))
+	case down == 0:
+		return greats(up, gendered(gender, "father", "mother", "parent"), gendered(gender, "grandfather", "grandmother", "grandparent"))
+	case up == 1 && down == 1:
+		return gendered(gender, "brother", "sister", "sibling")
+	case up == 1:
+		return greats(down-1, gendered(gender, "nephew", "niece", "nibling"), "grand"+gendered(gender, "nephew", "niece", "nibling"))
+	case down == 1:
+		return greats(up-1, gendered(gender, "uncle", "aunt", "pibling"), "great-"+gendered(gender, "uncle", "aunt", "pibling"))
+	}
+
+	degree := up
+	if down < degree {
+		degree = down
+	}
+	label := ordinal(degree-1) + " cousin"
+	switch removed := up - down; {
+	case removed == 1 || removed == -1:
+		label += " once removed"
+	case removed == 2 || removed == -2:
+		label += " twice removed"
+	case removed != 0:
+		if removed < 0 {
+			removed = -removed
+		}
+		label += fmt.Sprintf(" %d times removed", removed)
+	}
+	return label
+}
+
+// greats returns first for one generation, second for two, and second with
+// "great-" prefixes beyond that.
+func greats(generations int, first, second string) string {
+	if generations <= 1 {
+		return first
+	}
+	return strings.Repeat("great-", generations-2) + second
+}
+
+func gendered(gender, male, female, neutral string) string {
+	switch gender {
+	case "male":
+		return male
+	case "female":
+		return female
+	}
+	return neutral
+}
+
+func nearestRelatives(name string, limit int, explain bool) {
+	familyTree := loadFamilyTree()
+
+	if _, exists := familyTree[name]; !exists {
+		fmt.Printf("%s is not in the family tree.\n", name)
+		os.Exit(1)
+	}
+
+	distance, previous := routesWithin(familyTree, name, -1)
+	var relatives []string
+	for relative := range distance {
+		if relative != name {
+			relatives = append(relatives, relative)
+		}
+	}
+	if len(relatives) == 0 {
+		fmt.Printf("%s has no recorded relatives.\n", name)
+		return
+	}
+
+	// Closest first, ties alphabetically.
+	sort.Slice(relatives, func(i, j int) bool {
+		if distance[relatives[i]] != distance[relatives[j]] {
+			return distance[relatives[i]] < distance[relatives[j]]
+		}
+		return relatives[i] < relatives[j]
+	})
+	if len(relatives) > limit {
+		relatives = relatives[:limit]
+	}
+
+	fmt.Printf("Nearest relatives of %s:\n", name)
+	for _, relative := range relatives {
+		label := kinshipLabel(familyTree, name, relative)
+		if label == "" {
+			label = "relative"
+		}
+		fmt.Printf("  %-20s %-25s %d %s\n", relative, label, distance[relative], plural(distance[relative], "step"))
+		if explain {
+			fmt.Printf("      via %s\n", describeRoute(familyTree, routeTo(previous, relative)))
+		}
+	}
+}
+
+// relativeGeneration returns how many generations other is below name
+// (negative for older generations). Relatives by marriage take the
+// generation of the spouse that connects them. ok is false when the two are
+// not connected by blood or marriage.
+func relativeGeneration(familyTree map[string]Person, name, other string) (generation int, ok bool) {
+	if _, up, down, ok := commonAncestor(familyTree, name, other); ok {
+		return down - up, true
+	}
+	for _, spouse := range spousesOf(familyTree, other) {
+		if _, up, down, ok := commonAncestor(familyTree, name, spouse); ok {
+			return down - up, true
+		}
+	}
+	for _, spouse := range spousesOf(familyTree, name) {
+		if _, up, down, ok := commonAncestor(familyTree, spouse, other); ok {
+			return down - up, true
+		}
+	}
+	return 0, false
+}
+
+// ancestorPaths returns every line of ascent from name, each starting with
+// name itself, including the line of length zero that is just name. A line
+// never visits the same person twice.
+func ancestorPaths(familyTree map[string]Person, name string) [][]string {
+	var paths [][]string
+	var climb func(path []string)
+	climb = func(path []string) {
+		paths = append(paths, path)
+		for _, parent := range parentsOf(familyTree, path[len(path)-1]) {
+			if beyondGenerationLimit(len(path)) {
+				break
+			}
+			if !contains(path, parent) {
+				climb(append(append([]string{}, path...), parent))
+			}
+		}
+	}
+	climb([]string{name})
+	return paths
+}
+
+// kinshipCoefficient prints Wright's coefficient of relationship between a
+// and b, found by path counting:
+//
+//	r = Σ (1/2)^(n1+n2)
+//
+// summed over every pair of lines of ascent from a and from b that meet at a
+// common ancestor and share no one else, where n1 and n2 are the number of
+// generations on each side. Several common ancestors, or several routes to
+// one, each add their own term. The kinship coefficient is half of r. The
+// inbreeding of the common ancestors themselves is not taken into account.
+func kinshipCoefficient(a, b string) {
+	familyTree := loadFamilyTree()
+	for _, name := range []string{a, b} {
+		if _, exists := familyTree[name]; !exists {
+			fmt.Printf("%s is not in the family tree.\n", name)
+			os.Exit(1)
+		}
+	}
+	if a == b {
+		fmt.Println("Please name two different people.")
+		os.Exit(1)
+	}
+
+	contributions := make(map[string]float64)
+	pathsB := ancestorPaths(familyTree, b)
+	for _, pathA := range ancestorPaths(familyTree, a) {
+		ancestor := pathA[len(pathA)-1]
+		for _, pathB := range pathsB {
+			if pathB[len(pathB)-1] != ancestor || sharesAncestor(pathA, pathB) {
+				continue
+			}
+			generations := len(pathA) - 1 + len(pathB) - 1
+			contributions[ancestor] += math.Pow(0.5, float64(generations))
+		}
+	}
+
+	if len(contributions) == 0 {
+		fmt.Printf("%s and %s have no common ancestor in the family tree; the coefficient of relationship is 0.\n", a, b)
+		return
+	}
+
+	r := 0.0
+	ancestors := make([]string, 0, len(contributions))
+	for ancestor, contribution := range contributions {
+		r += contribution
+		ancestors = append(ancestors, ancestor)
+	}
+	sort.Strings(ancestors)
+
+	fmt.Printf("Coefficient of relationship between %s and %s: %.4f (%.2f%%)\n", a, b, r, r*100)
+	fmt.Printf("Kinship coefficient: %.4f\n", r/2)
+	fmt.Println("Contributions by common ancestor:")
+	for _, ancestor := range ancestors {
+		fmt.Printf("  %-25s %.4f\n", ancestor, contributions[ancestor])
+	}
+}
+
+// sharesAncestor reports whether two lines of ascent that end at the same
+// ancestor have anyone else in common.
+func sharesAncestor(pathA, pathB []string) bool {
+	for _, name := range pathA[:len(pathA)-1] {
+		if contains(pathB[:len(pathB)-1], name) {
+			return true
+		}
+	}
+	return false
+}
+
+// printWithin lists everyone reachable from name within hops relation hops,
+// grouped by distance. hops is capped at maxGenerations.
+func printWithin(name string, hops int, explain bool) {
+	familyTree := loadFamilyTree()
+
+	if _, exists := familyTree[name]; !exists {
+		fmt.Printf("%s is not in the family tree.\n", name)
+		os.Exit(1)
+	}
+
+	limit := hops
+	if limit > maxGenerations {
+		limit = maxGenerations
+	}
+	distances, previous := routesWithin(familyTree, name, limit)
+	if limit < hops && len(distancesWithin(familyTree, name, limit+1)) > len(distances) {
+		beyondGenerationLimit(limit + 1)
+	}
+	byDistance := make(map[int][]string)
+	for relative, distance := range distances {
+		if relative != name {
+			byDistance[distance] = append(byDistance[distance], relative)
+		}
+	}
+	if len(byDistance) == 0 {
+		fmt.Printf("No one is within %d %s of %s.\n", hops, plural(hops, "step"), name)
+		return
+	}
+
+	total := 0
+	for distance := 1; distance <= limit; distance++ {
+		relatives := byDistance[distance]
+		if len(relatives) == 0 {
+			continue
+		}
+		sort.Strings(relatives)
+		fmt.Printf("%d %s away:\n", distance, plural(distance, "step"))
+		for _, relative := range relatives {
+			if explain {
+				fmt.Printf("  %s, via %s\n", relative, describeRoute(familyTree, routeTo(previous, relative)))
+			} else {
+				fmt.Printf("  %s\n", relative)
+			}
+		}
+		total += len(relatives)
+	}
+	fmt.Printf("Total: %d\n", total)
+}
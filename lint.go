@@ -0,0 +1,62 @@
+package main
+
+import "fmt"
+
+// lint reports self-referential and duplicated relations, which otherwise
+// pass silently and inflate counts. With fix, self-relations are removed.
+func lint(fix bool) {
+	familyTree := loadFamilyTree()
+
+	issues, selfRelations := 0, 0
+	for _, name := range sortedNames(familyTree) {
+		seen := make(map[Relation]int)
+		for _, relation := range familyTree[name].Relations {
+			if relation.Target == name {
+				if siblingTypes[relation.Type] {
+					fmt.Printf("%s is recorded as their own %s.\n", name, relation.Type)
+				} else {
+					fmt.Printf("%s has a %s relation to themselves.\n", name, relation.Type)
+				}
+				issues++
+				selfRelations++
+				continue
+			}
+			if relation.Target != "" {
+				seen[relation]++
+			}
+		}
+		for _, relation := range familyTree[name].Relations {
+			if count := seen[relation]; count > 1 {
+				fmt.Printf("%s lists %s as %s %d times.\n", name, relation.Target, relation.Type, count)
+				issues++
+				seen[relation] = 0
+			}
+		}
+	}
+
+	if issues == 0 {
+		fmt.Println("No problems found.")
+		return
+	}
+	fmt.Printf("\n%d %s found.\n", issues, plural(issues, "problem"))
+
+	if !fix || selfRelations == 0 {
+		if selfRelations > 0 {
+			fmt.Println("Run with --fix to remove self-relations.")
+		}
+		return
+	}
+
+	for name, person := range familyTree {
+		relations := []Relation{}
+		for _, relation := range person.Relations {
+			if relation.Target != name {
+				relations = append(relations, relation)
+			}
+		}
+		person.Relations = relations
+		familyTree[name] = person
+	}
+	saveFamilyTree(familyTree)
+	fmt.Printf("Removed %d %s.\n", selfRelations, plural(selfRelations, "self-relation"))
+}
@@ -1,38 +1,144 @@
 package main
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
-const familyTreeFile = "family_tree.json"
+// familyTreeFile is the path of the family tree file, set with --file. Paths
+// ending in ".gz" are stored gzip-compressed.
+var familyTreeFile = "family_tree.json"
+
+// mutatingCommands lists the commands that write to the family tree file.
+// They are refused when --read-only is set.
+var mutatingCommands = map[string]bool{
+	"add":             true,
+	"cite":            true,
+	"connect":         true,
+	"import":          true,
+	"import-photos":   true,
+	"infer-gender":    true,
+	"normalize":       true,
+	"normalize-types": true,
+	"remove":          true,
+	"rename-surname":  true,
+	"set":             true,
+	"tag":             true,
+}
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+var errReadOnly = errors.New("the family tree is opened with --read-only")
+
+// ErrPersonNotFound is returned by queries about a person who is not in the
+// family tree. The returned error wraps it together with the person's name,
+// so callers should test for it with errors.Is.
+var ErrPersonNotFound = errors.New("not in the family tree")
+
+func personNotFound(name string) error {
+	return fmt.Errorf("%s is %w", name, ErrPersonNotFound)
+}
 
 // Person represents an individual in the family tree.
 type Person struct {
-	Name      string   `json:"name"`
-	Relations []string `json:"relations"`
+	Name      string     `json:"name"`
+	Gender    string     `json:"gender,omitempty"`
+	Birth     string     `json:"birth,omitempty"`
+	Death     string     `json:"death,omitempty"`
+	Tags      []string   `json:"tags,omitempty"`
+	Sources   []string   `json:"sources,omitempty"`
+	Photo     string     `json:"photo,omitempty"`
+	Relations []Relation `json:"relations"`
 }
 
+// Relation records that the person holding it is Type of Target. For
+// example, the relation {son, KK} on Amit means Amit is KK's son. Relations
+// migrated from old files have no Target.
+type Relation struct {
+	Type   string `json:"type"`
+	Target string `json:"target,omitempty"`
+}
+
+// Global options, set by flags that may appear anywhere on the command line.
+var (
+	encryptOnWrite bool
+	readOnly       bool
+	strictArgs     bool
+	debugMode      bool
+
+	// globalArgs holds the global flags that select the family tree file, for
+	// passing on to child processes.
+	globalArgs []string
+)
+
 func main() {
-	createFamilyTreeFile()
+	defer recoverFromPanic()
+	parseGlobalFlags()
+	if !readOnly && rootScope == "" {
+		createFamilyTreeFile()
+	}
 
 	if len(os.Args) < 2 {
-		fmt.Println("Usage: family-tree <command> [options]")
+		fmt.Println("Usage: family-tree [global options] <command> [options]")
 		fmt.Println("\nCommands:")
-		fmt.Println("  add person       Add a person to the family tree")
-		fmt.Println("  add relationship Add a relationship to a person in the family tree")
-		fmt.Println("  connect          Connect two people in the family tree")
-		fmt.Println("  countsons        Count the number of sons for an individual")
-		fmt.Println("  countdaughters   Count the number of daughters for an individual")
-		fmt.Println("  countwives       Count the number of wives for an individual")
-		fmt.Println("  father           Find the father of an individual")
-		fmt.Println("  help             Show available commands")
+		printCommands()
 		os.Exit(1)
 	}
 
+	start := time.Now()
+	stopProfile := startProfile()
+	if os.Args[1] == "-" {
+		runCommands(stdin)
+	} else {
+		runCommand()
+	}
+	stopProfile()
+	reportTiming(start)
+}
+
+// recoverFromPanic turns a panic, usually caused by a malformed family tree
+// file, into a short error message. The stack trace is only shown with
+// --debug.
+func recoverFromPanic() {
+	if r := recover(); r != nil {
+		fmt.Fprintf(os.Stderr, "Internal error: %v\n", r)
+		if debugMode {
+			os.Stderr.Write(debug.Stack())
+		} else {
+			fmt.Fprintln(os.Stderr, "The family tree file may be malformed. Run again with --debug to see the stack trace.")
+		}
+		os.Exit(1)
+	}
+}
+
+// runCommand executes the command given in os.Args.
+func runCommand() {
 	command := os.Args[1]
+	if readOnly && mutatingCommands[command] {
+		fmt.Printf("Command '%s' modifies the family tree and is blocked by --read-only.\n", command)
+		os.Exit(1)
+	}
+	if rootScope != "" && mutatingCommands[command] {
+		fmt.Printf("Command '%s' modifies the family tree and cannot be scoped with --root.\n", command)
+		os.Exit(1)
+	}
+	if strictArgs {
+		if err := checkArgs(command, os.Args[2:]); err != nil {
+			fmt.Printf("Invalid arguments: %v. Use 'help' to see the usage.\n", err)
+			os.Exit(1)
+		}
+	}
+
 	switch command {
 	case "add":
 		if len(os.Args) < 3 {
@@ -50,7 +156,7 @@ func main() {
 			addPerson(name)
 		case "relationship":
 			if len(os.Args) < 4 {
-				fmt.Println("Usage: family-tree add relationship <name>")
+				fmt.Println("Usage: family-tree add relationship <name> <relationship> [of <name2>]")
 				os.Exit(1)
 			}
 			name := os.Args[3]
@@ -59,72 +165,725 @@ func main() {
 			fmt.Println("Unknown subcommand for 'add'. Use 'person' or 'relationship'.")
 			os.Exit(1)
 		}
+	case "remove":
+		if len(os.Args) < 4 || os.Args[2] != "person" {
+			fmt.Println("Usage: family-tree remove person <name>")
+			os.Exit(1)
+		}
+		removePerson(os.Args[3])
 	case "connect":
-		if len(os.Args) < 7 || os.Args[4] != "as" || os.Args[6] != "of" {
+		if len(os.Args) < 7 || os.Args[3] != "as" || os.Args[5] != "of" {
 			fmt.Println("Usage: family-tree connect <name1> as <relationship> of <name2>")
 			os.Exit(1)
 		}
 		name1 := os.Args[2]
-		relationship := os.Args[5]
-		name2 := os.Args[7]
+		relationship := os.Args[4]
+		name2 := os.Args[6]
 		connectPeople(name1, relationship, name2)
 	case "countsons":
 		if len(os.Args) < 3 {
-			fmt.Println("Usage: family-tree countsons <name>")
+			fmt.Println("Usage: family-tree countsons <name> [--living] [--verbose]")
 			os.Exit(1)
 		}
 		name := os.Args[2]
-		count := countSons(name)
-		fmt.Printf("%s has %d sons.\n", name, count)
+		printKinCount(name, "son", countSons, os.Args[3:])
 	case "countdaughters":
 		if len(os.Args) < 3 {
-			fmt.Println("Usage: family-tree countdaughters <name>")
+			fmt.Println("Usage: family-tree countdaughters <name> [--living] [--verbose]")
+			os.Exit(1)
+		}
+		name := os.Args[2]
+		printKinCount(name, "daughter", countDaughters, os.Args[3:])
+	case "countchildren":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: family-tree countchildren <name> [--living] [--verbose]")
+			os.Exit(1)
+		}
+		name := os.Args[2]
+		printKinCount(name, "child", countChildren, os.Args[3:])
+	case "countbrothers":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: family-tree countbrothers <name> [--living] [--verbose]")
 			os.Exit(1)
 		}
 		name := os.Args[2]
-		count := countDaughters(name)
-		fmt.Printf("%s has %d daughters.\n", name, count)
+		printKinCount(name, "brother", countBrothers, os.Args[3:])
+	case "countsisters":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: family-tree countsisters <name> [--living] [--verbose]")
+			os.Exit(1)
+		}
+		name := os.Args[2]
+		printKinCount(name, "sister", countSisters, os.Args[3:])
 	case "countwives":
 		if len(os.Args) < 3 {
-			fmt.Println("Usage: family-tree countwives <name>")
+			fmt.Println("Usage: family-tree countwives <name> [--living] [--verbose]")
 			os.Exit(1)
 		}
 		name := os.Args[2]
-		count := countWives(name)
-		fmt.Printf("%s has %d wives.\n", name, count)
+		printKinCount(name, "wife", countWives, os.Args[3:])
+	case "countcousins":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: family-tree countcousins <name> [--degree N]")
+			os.Exit(1)
+		}
+		name := os.Args[2]
+		degree := 1
+		if len(os.Args) >= 5 && os.Args[3] == "--degree" {
+			n, err := strconv.Atoi(os.Args[4])
+			if err != nil || n < 1 {
+				fmt.Println("The --degree option requires a positive number.")
+				os.Exit(1)
+			}
+			degree = n
+		}
+		count, err := countCousins(name, degree)
+		if err != nil {
+			fmt.Printf("%v.\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s has %d %s %s.\n", name, count, ordinal(degree), plural(count, "cousin"))
+	case "countancestors":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: family-tree countancestors <name> [--by-generation] [--completeness]")
+			os.Exit(1)
+		}
+		name := os.Args[2]
+		counts, err := countAncestorsByGeneration(name)
+		if err != nil {
+			fmt.Printf("%v.\n", err)
+			os.Exit(1)
+		}
+		byGeneration := hasFlag(os.Args[3:], "--by-generation")
+		switch {
+		case hasFlag(os.Args[3:], "--completeness"):
+			printAncestorCompleteness(name, counts, byGeneration)
+		case byGeneration:
+			printGenerationCounts(counts, ancestorGenerationName)
+		default:
+			total := 0
+			for _, count := range counts {
+				total += count
+			}
+			fmt.Printf("%s has %d %s.\n", name, total, plural(total, "ancestor"))
+		}
+	case "count":
+		if len(os.Args) >= 3 && os.Args[2] == "people" {
+			where, _ := flagValue(os.Args[3:], "--where")
+			countPeople(where, hasFlag(os.Args[3:], "--list"))
+			break
+		}
+		if len(os.Args) >= 4 && os.Args[2] == "marriages" {
+			countMarriages(os.Args[3])
+			break
+		}
+		if len(os.Args) >= 4 && os.Args[2] == "relatives" {
+			name := os.Args[3]
+			direction, _ := flagValue(os.Args[4:], "--direction")
+			value, ok := flagValue(os.Args[4:], "--depth")
+			depth, err := strconv.Atoi(value)
+			if (direction != "up" && direction != "down") || !ok || err != nil || depth < 0 {
+				fmt.Println("Usage: family-tree count relatives <name> --direction up|down --depth N")
+				os.Exit(1)
+			}
+			count, err := countRelativesAtDepth(name, direction, depth)
+			if err != nil {
+				fmt.Printf("%v.\n", err)
+				os.Exit(1)
+			}
+			if depth == 0 {
+				fmt.Printf("Depth 0 is %s alone.\n", name)
+				break
+			}
+			up, down := depth, 0
+			if direction == "down" {
+				up, down = 0, depth
+			}
+			fmt.Printf("%s has %d %s.\n", name, count, plural(count, bloodLabel(up, down, "unknown")))
+			break
+		}
+		if len(os.Args) < 4 || os.Args[2] != "descendants" {
+			fmt.Println("Usage: family-tree count descendants <name> [--by-generation]")
+			fmt.Println("       family-tree count people [--where <expression>] [--list]")
+			fmt.Println("       family-tree count relatives <name> --direction up|down --depth N")
+			fmt.Println("       family-tree count marriages <name>")
+			os.Exit(1)
+		}
+		name := os.Args[3]
+		counts, err := countDescendantsByGeneration(name)
+		if err != nil {
+			fmt.Printf("%v.\n", err)
+			os.Exit(1)
+		}
+		if len(os.Args) > 4 && os.Args[4] == "--by-generation" {
+			printGenerationCounts(counts, descendantGenerationName)
+		} else {
+			total := 0
+			for _, count := range counts {
+				total += count
+			}
+			fmt.Printf("%s has %d %s.\n", name, total, plural(total, "descendant"))
+		}
+	case "relationship-counts":
+		relationshipCounts(hasFlag(os.Args[2:], "--json"))
+	case "countroots":
+		printCount("roots", countWithout(parentsOf), hasFlag(os.Args[2:], "--json"))
+	case "countleaves":
+		printCount("leaves", countWithout(childrenOf), hasFlag(os.Args[2:], "--json"))
+	case "relation-types":
+		printRelationTypes()
 	case "father":
 		if len(os.Args) < 4 || os.Args[2] != "of" {
-			fmt.Println("Usage: family-tree father of <name>")
+			fmt.Println("Usage: family-tree father of <name> [--json]")
 			os.Exit(1)
 		}
 		name := os.Args[3]
 		fatherName := findFather(name)
+		if hasFlag(os.Args[4:], "--json") {
+			printParentJSON(name, "father", fatherName)
+			break
+		}
 		if fatherName != "" {
 			fmt.Printf("Father of %s is %s.\n", name, fatherName)
 		} else {
 			fmt.Printf("Father of %s is not in the family tree.\n", name)
 		}
+	case "mother":
+		if len(os.Args) < 4 || os.Args[2] != "of" {
+			fmt.Println("Usage: family-tree mother of <name> [--json]")
+			os.Exit(1)
+		}
+		name := os.Args[3]
+		motherName := findMother(name)
+		if hasFlag(os.Args[4:], "--json") {
+			printParentJSON(name, "mother", motherName)
+			break
+		}
+		if motherName != "" {
+			fmt.Printf("Mother of %s is %s.\n", name, motherName)
+		} else {
+			fmt.Printf("Mother of %s is not in the family tree.\n", name)
+		}
+	case "grandfather", "grandmother":
+		if len(os.Args) < 4 || os.Args[2] != "of" {
+			fmt.Printf("Usage: family-tree %s of <name>\n", command)
+			os.Exit(1)
+		}
+		printGrandparents(os.Args[3], command)
+	case "siblings":
+		if len(os.Args) < 4 || os.Args[2] != "of" {
+			fmt.Println("Usage: family-tree siblings of <name>")
+			os.Exit(1)
+		}
+		printSiblings(os.Args[3])
+	case "children":
+		if len(os.Args) < 4 || os.Args[2] != "of" {
+			fmt.Println("Usage: family-tree children of <name>")
+			os.Exit(1)
+		}
+		printChildren(os.Args[3])
+	case "grandchildren":
+		if len(os.Args) < 4 || os.Args[2] != "of" {
+			fmt.Println("Usage: family-tree grandchildren of <name>")
+			os.Exit(1)
+		}
+		printGrandchildren(os.Args[3])
+	case "branching":
+		branching()
+	case "verify-reciprocity":
+		fix := len(os.Args) > 2 && os.Args[2] == "--fix"
+		verifyReciprocity(fix)
+	case "tag":
+		if len(os.Args) < 5 || (os.Args[2] != "add" && os.Args[2] != "remove") {
+			fmt.Println("Usage: family-tree tag add|remove <name> <tag>")
+			os.Exit(1)
+		}
+		if os.Args[2] == "add" {
+			addTag(os.Args[3], os.Args[4])
+		} else {
+			removeTag(os.Args[3], os.Args[4])
+		}
+	case "find":
+		if len(os.Args) < 4 || os.Args[2] != "--tag" {
+			fmt.Println("Usage: family-tree find --tag <tag>")
+			os.Exit(1)
+		}
+		tag := os.Args[3]
+		names := findByTag(tag)
+		if len(names) == 0 {
+			fmt.Printf("No one is tagged '%s'.\n", tag)
+		}
+		for _, name := range names {
+			fmt.Println(name)
+		}
+	case "list":
+		listPeople(hasFlag(os.Args[2:], "--relations"))
+	case "show":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: family-tree show <name>")
+			os.Exit(1)
+		}
+		showPerson(os.Args[2])
+	case "longest-line":
+		longestLine()
+	case "nearest-relatives":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: family-tree nearest-relatives <name> [N] [--explain]")
+			os.Exit(1)
+		}
+		limit := 5
+		if len(os.Args) >= 4 && os.Args[3] != "--explain" {
+			n, err := strconv.Atoi(os.Args[3])
+			if err != nil || n < 1 {
+				fmt.Println("The number of relatives must be a positive number.")
+				os.Exit(1)
+			}
+			limit = n
+		}
+		nearestRelatives(os.Args[2], limit, hasFlag(os.Args[3:], "--explain"))
+	case "within":
+		if len(os.Args) < 4 {
+			fmt.Println("Usage: family-tree within <name> <hops> [--explain]")
+			os.Exit(1)
+		}
+		hops, err := strconv.Atoi(os.Args[3])
+		if err != nil || hops < 1 {
+			fmt.Println("The number of hops must be a positive number.")
+			os.Exit(1)
+		}
+		printWithin(os.Args[2], hops, hasFlag(os.Args[4:], "--explain"))
+	case "explain":
+		if len(os.Args) < 4 {
+			fmt.Println("Usage: family-tree explain <name1> <name2>")
+			os.Exit(1)
+		}
+		explainRelationship(os.Args[2], os.Args[3])
+	case "set":
+		if len(os.Args) >= 5 && os.Args[2] == "gender" {
+			setGender(os.Args[3], os.Args[4])
+			break
+		}
+		if len(os.Args) < 5 || (os.Args[2] != "birth" && os.Args[2] != "death") {
+			fmt.Println("Usage: family-tree set birth|death <name> <[abt ]YYYY[-MM[-DD]]> [--no-validate]")
+			fmt.Println("       family-tree set gender <name> male|female|unknown")
+			os.Exit(1)
+		}
+		validate := !(len(os.Args) > 5 && os.Args[5] == "--no-validate")
+		setDate(os.Args[2], os.Args[3], os.Args[4], validate)
+	case "lifespans":
+		lifespans(parseMaxAge(os.Args[2:]))
+	case "same-birthday":
+		sameBirthday()
+	case "generation-gaps":
+		generationGaps(parseAgeLimits(os.Args[2:]))
+	case "export":
+		if len(os.Args) >= 3 && os.Args[2] == "mermaid" {
+			output, _ := flagValue(os.Args[3:], "--output")
+			exportMermaid(output)
+			break
+		}
+		if len(os.Args) < 4 {
+			fmt.Println("Usage: family-tree export nested|subtree|labels|register-text <name> [options]")
+			fmt.Println("       family-tree export mermaid [--output <file>]")
+			os.Exit(1)
+		}
+		name := os.Args[3]
+		switch os.Args[2] {
+		case "nested":
+			exportNested(name, hasFlag(os.Args[4:], "--anonymize-living"), hasFlag(os.Args[4:], "--parents-first"))
+		case "subtree":
+			up, down := 0, 0
+			for flag, target := range map[string]*int{"--up": &up, "--down": &down} {
+				if value, ok := flagValue(os.Args[4:], flag); ok {
+					n, err := strconv.Atoi(value)
+					if err != nil || n < 0 {
+						fmt.Printf("The %s option requires a number of generations.\n", flag)
+						os.Exit(1)
+					}
+					*target = n
+				}
+			}
+			output, _ := flagValue(os.Args[4:], "--output")
+			exportSubtree(name, up, down, output, hasFlag(os.Args[4:], "--parents-first"))
+		case "labels":
+			output, _ := flagValue(os.Args[4:], "--output")
+			exportLabels(name, output)
+		case "register-text":
+			width := outputWidth()
+			if value, ok := flagValue(os.Args[4:], "--width"); ok {
+				n, err := strconv.Atoi(value)
+				if err != nil || n < 20 {
+					fmt.Println("The --width option requires a number of columns (at least 20).")
+					os.Exit(1)
+				}
+				width = n
+			}
+			exportRegisterText(name, width)
+		default:
+			fmt.Println("Unknown export format. Use 'nested', 'subtree', 'labels', 'register-text' or 'mermaid'.")
+			os.Exit(1)
+		}
+	case "anonymize":
+		output, _ := flagValue(os.Args[2:], "--output")
+		anonymize(output)
+	case "cleanup":
+		if len(os.Args) < 3 || os.Args[2] != "--orphans" {
+			fmt.Println("Usage: family-tree cleanup --orphans [--remove]")
+			os.Exit(1)
+		}
+		remove := len(os.Args) > 3 && os.Args[3] == "--remove"
+		cleanupOrphans(remove)
+	case "edges":
+		printEdges(hasFlag(os.Args[2:], "--json"), hasFlag(os.Args[2:], "--parents-first"))
+	case "find-orphans":
+		findOrphans()
+	case "ancestors":
+		if len(os.Args) < 4 || os.Args[2] != "of" {
+			fmt.Println("Usage: family-tree ancestors of <name>")
+			os.Exit(1)
+		}
+		printAncestors(os.Args[3])
+	case "ancestors-at-level":
+		if len(os.Args) < 4 {
+			fmt.Println("Usage: family-tree ancestors-at-level <name> <n> [--with-spouses]")
+			os.Exit(1)
+		}
+		level, err := strconv.Atoi(os.Args[3])
+		if err != nil || level < 1 {
+			fmt.Println("The level must be a positive number (1 for parents, 2 for grandparents, ...).")
+			os.Exit(1)
+		}
+		printAncestorsAtLevel(os.Args[2], level, hasFlag(os.Args[4:], "--with-spouses"))
+	case "lineage":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: family-tree lineage <name> [--line paternal|maternal|both] [--with-spouses]")
+			os.Exit(1)
+		}
+		line := "both"
+		if value, ok := flagValue(os.Args[3:], "--line"); ok {
+			line = value
+		}
+		if line != "paternal" && line != "maternal" && line != "both" {
+			fmt.Println("The --line option must be paternal, maternal or both.")
+			os.Exit(1)
+		}
+		printLineage(os.Args[2], line, hasFlag(os.Args[3:], "--with-spouses"))
+	case "descendants-at-level":
+		if len(os.Args) < 4 {
+			fmt.Println("Usage: family-tree descendants-at-level <name> <n> [--with-spouses]")
+			os.Exit(1)
+		}
+		level, err := strconv.Atoi(os.Args[3])
+		if err != nil || level < 1 {
+			fmt.Println("The level must be a positive number (1 for children, 2 for grandchildren, ...).")
+			os.Exit(1)
+		}
+		printDescendantsAtLevel(os.Args[2], level, hasFlag(os.Args[4:], "--with-spouses"))
+	case "rename-surname":
+		if len(os.Args) < 4 {
+			fmt.Println("Usage: family-tree rename-surname <old> <new> [--under <name>]")
+			os.Exit(1)
+		}
+		under := ""
+		if len(os.Args) >= 6 && os.Args[4] == "--under" {
+			under = os.Args[5]
+		}
+		renameSurname(os.Args[2], os.Args[3], under)
+	case "health":
+		printHealth(hasFlag(os.Args[2:], "--json"))
+	case "stats":
+		if !hasFlag(os.Args[2:], "--watch") {
+			printStats()
+			break
+		}
+		interval := 2 * time.Second
+		if value, ok := flagValue(os.Args[2:], "--interval"); ok {
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				fmt.Println("The --interval option requires a duration such as 2s or 500ms.")
+				os.Exit(1)
+			}
+			interval = d
+		}
+		watchStats(interval)
+	case "remarriages":
+		printRemarriages()
+	case "roots":
+		printRoots()
+	case "surnames":
+		printSurnames()
+	case "compare-branches":
+		if len(os.Args) < 4 {
+			fmt.Println("Usage: family-tree compare-branches <name1> <name2>")
+			os.Exit(1)
+		}
+		compareBranches(os.Args[2], os.Args[3])
+	case "tree":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: family-tree tree <name> [--style ascii|unicode] [--json]")
+			os.Exit(1)
+		}
+		style, _ := flagValue(os.Args[3:], "--style")
+		printTree(os.Args[2], style, hasFlag(os.Args[3:], "--json"))
+	case "kinship-coefficient":
+		if len(os.Args) < 4 {
+			fmt.Println("Usage: family-tree kinship-coefficient <name1> <name2>")
+			os.Exit(1)
+		}
+		kinshipCoefficient(os.Args[2], os.Args[3])
+	case "check-spouses":
+		checkSpouses(hasFlag(os.Args[2:], "--monogamy"))
+	case "normalize":
+		normalizeFile()
+	case "normalize-types":
+		normalizeTypes(parseTypeMap(os.Args[2:]))
+	case "import":
+		encoding, ok := flagValue(os.Args[2:], "--input-encoding")
+		if !ok {
+			encoding = "utf-8"
+		}
+		switch {
+		case len(os.Args) >= 4 && os.Args[2] == "outline":
+			importFile(os.Args[3], "outline", encoding)
+		case len(os.Args) >= 3 && !strings.HasPrefix(os.Args[2], "--"):
+			importFile(os.Args[2], "", encoding)
+		default:
+			fmt.Println("Usage: family-tree import [outline] <file> [--input-encoding utf-8|latin1|ansel]")
+			os.Exit(1)
+		}
+	case "import-photos":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: family-tree import-photos <dir>")
+			os.Exit(1)
+		}
+		importPhotos(os.Args[2])
+	case "infer-gender":
+		inferGender(hasFlag(os.Args[2:], "--dry-run"))
+	case "snapshot":
+		switch {
+		case len(os.Args) == 3 && os.Args[2] == "list":
+			listSnapshots()
+		case len(os.Args) >= 4 && os.Args[2] == "restore":
+			restoreSnapshot(os.Args[3])
+		case len(os.Args) >= 3 && os.Args[2] != "list" && os.Args[2] != "restore":
+			takeSnapshot(os.Args[2])
+		default:
+			fmt.Println("Usage: family-tree snapshot <label> | snapshot list | snapshot restore <label>")
+			os.Exit(1)
+		}
+	case "lint":
+		lint(hasFlag(os.Args[2:], "--fix"))
+	case "cite":
+		if len(os.Args) < 4 {
+			fmt.Println("Usage: family-tree cite <name> <source>")
+			os.Exit(1)
+		}
+		cite(os.Args[2], os.Args[3])
+	case "watch":
+		interval := time.Second
+		query := os.Args[2:]
+		if len(query) >= 2 && query[0] == "--interval" {
+			d, err := time.ParseDuration(query[1])
+			if err != nil || d <= 0 {
+				fmt.Println("The --interval option requires a duration such as 2s or 500ms.")
+				os.Exit(1)
+			}
+			interval = d
+			query = query[2:]
+		}
+		if len(query) == 0 {
+			fmt.Println("Usage: family-tree watch [--interval 1s] <command> [arguments]")
+			os.Exit(1)
+		}
+		watch(query, interval)
 	case "help":
 		fmt.Println("Available commands:")
-		fmt.Println("  add person       Add a person to the family tree")
-		fmt.Println("  add relationship Add a relationship to a person in the family tree")
-		fmt.Println("  connect          Connect two people in the family tree")
-		fmt.Println("  countsons        Count the number of sons for an individual")
-		fmt.Println("  countdaughters   Count the number of daughters for an individual")
-		fmt.Println("  countwives       Count the number of wives for an individual")
-		fmt.Println("  father           Find the father of an individual")
-		fmt.Println("  help             Show available commands")
+		printCommands()
 	default:
 		fmt.Println("Unknown command. Use 'help' to see available commands.")
 		os.Exit(1)
 	}
+
+	if generationsTruncated {
+		// On stderr, so the note cannot corrupt JSON output.
+		fmt.Fprintf(os.Stderr, "Note: stopped after %d %s; raise --max-generations to see more.\n", maxGenerations, plural(maxGenerations, "generation"))
+		generationsTruncated = false
+	}
+}
+
+func printCommands() {
+	fmt.Println("  add person       Add a person to the family tree")
+	fmt.Println("  add relationship Add a relationship to a person in the family tree")
+	fmt.Println("  remove person    Remove a person and every relation pointing to them")
+	fmt.Println("  connect          Connect two people in the family tree")
+	fmt.Println("  countsons        Count the number of sons for an individual (--living [--verbose])")
+	fmt.Println("  countdaughters   Count the number of daughters for an individual (--living [--verbose])")
+	fmt.Println("  countchildren    Count the number of children for an individual (--living [--verbose])")
+	fmt.Println("  countbrothers    Count the number of brothers for an individual (--living [--verbose])")
+	fmt.Println("  countsisters     Count the number of sisters for an individual (--living [--verbose])")
+	fmt.Println("  countwives       Count the number of wives for an individual (--living [--verbose])")
+	fmt.Println("  countcousins     Count the first (or --degree N) cousins of an individual")
+	fmt.Println("  countancestors   Count a person's ancestors (--by-generation, --completeness)")
+	fmt.Println("  count descendants Count a person's descendants (--by-generation for a breakdown)")
+	fmt.Println("  count relatives  Count ancestors or descendants exactly N generations away (--direction up|down --depth N)")
+	fmt.Println("  count marriages  Count a person's marriages, current and former")
+	fmt.Println("  count people     Count people matching --where \"gender=female AND generation>2\" (--list names them)")
+	fmt.Println("  countroots       Print the number of people with no recorded parents (--json)")
+	fmt.Println("  countleaves      Print the number of people with no recorded children (--json)")
+	fmt.Println("  relation-types   List the distinct relationship types in use, flagging unrecognised ones")
+	fmt.Println("  relationship-counts Tally the relationships of each type across the tree (--json)")
+	fmt.Println("  father           Find the father of an individual (--json)")
+	fmt.Println("  mother           Find the mother of an individual (--json)")
+	fmt.Println("  grandfather      Find the grandfathers of an individual on both sides")
+	fmt.Println("  grandmother      Find the grandmothers of an individual on both sides")
+	fmt.Println("  siblings         List the people sharing a parent with an individual")
+	fmt.Println("  children         List the children of an individual")
+	fmt.Println("  grandchildren    List the grandchildren of an individual")
+	fmt.Println("  branching        Show how many children each parent has")
+	fmt.Println("  verify-reciprocity Report relations missing their reverse link (--fix adds them)")
+	fmt.Println("  tag add|remove   Add or remove a free-form tag on a person")
+	fmt.Println("  find --tag       List everyone carrying a tag")
+	fmt.Println("  list             List everyone in the family tree (--relations shows their relations)")
+	fmt.Println("  show             Show a person's details")
+	fmt.Println("  longest-line     Show the longest documented line of descent")
+	fmt.Println("  nearest-relatives List the N closest relatives of a person (default 5; --explain shows the path)")
+	fmt.Println("  explain          Explain in a sentence how the second person is related to the first")
+	fmt.Println("  within           List everyone within N relationship hops of a person, by distance (--explain)")
+	fmt.Println("  set birth|death  Record a person's birth or death date")
+	fmt.Println("  set gender       Record a person's gender (male, female or unknown)")
+	fmt.Println("  lifespans        Show ages at death (--max-age N lists those who died younger)")
+	fmt.Println("  same-birthday    List people who share a birthday (month and day)")
+	fmt.Println("  generation-gaps  Flag parents implausibly young or old at a child's birth (--min-age 12, --max-age 65)")
+	fmt.Println("  export nested    Print a person's descendants as nested JSON (--parents-first)")
+	fmt.Println("  anonymize        Write a copy with names replaced by Person1, Person2, ... and personal details removed (--output)")
+	fmt.Println("  export subtree   Save a person with --up U and --down D generations as a new tree file (--parents-first)")
+	fmt.Println("  export labels    Export everyone's relationship to an anchor person as JSON")
+	fmt.Println("  export register-text Write a Register-style descendant report with cited sources")
+	fmt.Println("  export mermaid   Write the tree as a Mermaid diagram for Markdown (--output)")
+	fmt.Println("  cleanup --orphans List people with no relationships (--remove deletes them)")
+	fmt.Println("  edges            List every relation as 'from type to' (--json for JSON, --parents-first)")
+	fmt.Println("  find-orphans     Flag people who probably have parents missing from the tree")
+	fmt.Println("  ancestors        List all ancestors of an individual, grouped by generation")
+	fmt.Println("  ancestors-at-level List ancestors exactly n generations up (--with-spouses)")
+	fmt.Println("  lineage          Trace the direct paternal and/or maternal line of a person (--with-spouses)")
+	fmt.Println("  descendants-at-level List descendants exactly n generations down (--with-spouses)")
+	fmt.Println("  rename-surname   Change a surname for everyone (or --under a person's branch)")
+	fmt.Println("  health           Show a data quality score for the tree (--json for JSON)")
+	fmt.Println("  stats            Summarise the family tree (--watch [--interval 2s] refreshes it live)")
+	fmt.Println("  remarriages      List people married more than once")
+	fmt.Println("  roots            List the separate families in the file and who heads each")
+	fmt.Println("  surnames         Show how many people share each surname")
+	fmt.Println("  compare-branches Compare the descendant branches of two people side by side")
+	fmt.Println("  normalize        Tidy names and relations and rewrite the file canonically (with a backup)")
+	fmt.Println("  normalize-types  Rewrite synonym relationship types to canonical ones (--map from=to adds synonyms)")
+	fmt.Println("  import           Import a file, detecting its format ('import outline <file>' to be explicit; --input-encoding latin1|ansel for older files)")
+	fmt.Println("  import-photos    Set people's photos from image files in a directory named after them")
+	fmt.Println("  infer-gender     Record genders implied by relation types (--dry-run to preview)")
+	fmt.Println("  snapshot         Save a named snapshot of the file ('snapshot list', 'snapshot restore <label>')")
+	fmt.Println("  lint             Report self-relations and duplicate relations (--fix removes self-relations)")
+	fmt.Println("  tree             Draw a person's descendants as a chart (--style ascii|unicode, --json)")
+	fmt.Println("  kinship-coefficient Compute the coefficient of relationship between two people")
+	fmt.Println("  check-spouses    Report one-sided spouse relations (--monogamy also flags possibly concurrent spouses)")
+	fmt.Println("  cite             Record a source citation for a person")
+	fmt.Println("  watch            Re-run a query whenever the family tree file changes")
+	fmt.Println("  help             Show available commands")
+	fmt.Println("  -                Read commands from standard input, one per line")
+	fmt.Println("\nGlobal options:")
+	fmt.Println("  --encrypt        Encrypt the family tree file with a passphrase (from " + passphraseEnv + " or a prompt)")
+	fmt.Println("  --file <path>    Use a different family tree file (a .json.gz path is gzip-compressed)")
+	fmt.Println("  --read-only      Never modify the family tree file; blocks: " + strings.Join(blockedCommands(), ", "))
+	fmt.Println("  --max-generations N Follow at most N generations in recursive traversals (default 200)")
+	fmt.Println("  --root <name>    Limit queries to the family <name> belongs to")
+	fmt.Println("  --name-order given-first|family-first Where the family name goes in full names (default given-first)")
+	fmt.Println("  --timing         Report how long loading, computing and saving took")
+	fmt.Println("  --debug          Show a stack trace when an internal error occurs")
+	fmt.Println("  --strict-args    Reject unexpected extra arguments instead of ignoring them")
+}
+
+// blockedCommands returns the sorted names of the commands refused by --read-only.
+func blockedCommands() []string {
+	var names []string
+	for name := range mutatingCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseGlobalFlags removes global flags from os.Args, so the per-command
+// argument handling in main only sees the command and its own arguments.
+func parseGlobalFlags() {
+	args := []string{os.Args[0]}
+	for i := 1; i < len(os.Args); i++ {
+		switch os.Args[i] {
+		case "--encrypt":
+			encryptOnWrite = true
+		case "--read-only":
+			readOnly = true
+		case "--max-generations":
+			if i+1 >= len(os.Args) {
+				fmt.Println("Global option '--max-generations' requires a number.")
+				os.Exit(1)
+			}
+			i++
+			n, err := strconv.Atoi(os.Args[i])
+			if err != nil || n < 1 {
+				fmt.Println("Global option '--max-generations' requires a positive number.")
+				os.Exit(1)
+			}
+			maxGenerations = n
+			globalArgs = append(globalArgs, "--max-generations", os.Args[i])
+		case "--root":
+			if i+1 >= len(os.Args) {
+				fmt.Println("Global option '--root' requires a name.")
+				os.Exit(1)
+			}
+			i++
+			rootScope = os.Args[i]
+			globalArgs = append(globalArgs, "--root", rootScope)
+		case "--name-order":
+			if i+1 >= len(os.Args) || (os.Args[i+1] != "given-first" && os.Args[i+1] != "family-first") {
+				fmt.Println("Global option '--name-order' must be given-first or family-first.")
+				os.Exit(1)
+			}
+			i++
+			nameOrder = os.Args[i]
+			globalArgs = append(globalArgs, "--name-order", nameOrder)
+		case "--profile":
+			// Deliberately left out of the help: a diagnostic for maintainers.
+			if i+1 >= len(os.Args) || (os.Args[i+1] != "cpu" && os.Args[i+1] != "mem") {
+				fmt.Println("Global option '--profile' must be cpu or mem.")
+				os.Exit(1)
+			}
+			i++
+			profileKind = os.Args[i]
+		case "--timing":
+			showTiming = true
+		case "--debug":
+			debugMode = true
+			globalArgs = append(globalArgs, "--debug")
+		case "--strict-args":
+			strictArgs = true
+			globalArgs = append(globalArgs, "--strict-args")
+		case "--file":
+			if i+1 >= len(os.Args) {
+				fmt.Println("Global option '--file' requires a path.")
+				os.Exit(1)
+			}
+			i++
+			familyTreeFile = os.Args[i]
+			globalArgs = append(globalArgs, "--file", familyTreeFile)
+		default:
+			args = append(args, os.Args[i])
+		}
+	}
+	os.Args = args
 }
 
 func createFamilyTreeFile() {
 	if _, err := os.Stat(familyTreeFile); os.IsNotExist(err) {
 		// Family tree file does not exist, create an empty one
-		initialData := make(map[string]Person)
-		data, err := json.Marshal(initialData)
+		data, err := encodeFamilyTree(make(map[string]Person))
 		if err != nil {
 			fmt.Printf("Error encoding family tree data: %v\n", err)
 			os.Exit(1)
@@ -138,52 +897,57 @@ func createFamilyTreeFile() {
 }
 
 func addPerson(name string) {
-	data, err := readFamilyTreeFile()
-	if err != nil {
-		fmt.Printf("Error reading family tree file: %v\n", err)
-		os.Exit(1)
-	}
-
-	var familyTree map[string]Person
-	err = json.Unmarshal(data, &familyTree)
-	if err != nil {
-		fmt.Printf("Error decoding family tree data: %v\n", err)
-		os.Exit(1)
-	}
+	familyTree := loadFamilyTree()
 
 	if _, exists := familyTree[name]; exists {
 		fmt.Printf("%s is already in the family tree.\n", name)
 	} else {
-		familyTree[name] = Person{Name: name, Relations: []string{}}
-		newData, err := json.MarshalIndent(familyTree, "", "  ")
-		if err != nil {
-			fmt.Printf("Error encoding family tree data: %v\n", err)
-			os.Exit(1)
-		}
-
-		err = writeFamilyTreeFile(newData)
-		if err != nil {
-			fmt.Printf("Error writing family tree file: %v\n", err)
-			os.Exit(1)
-		}
+		familyTree[name] = Person{Name: name, Relations: []Relation{}}
+		saveFamilyTree(familyTree)
 
 		fmt.Printf("Added %s to the family tree.\n", name)
 	}
 }
 
-func addRelationship(name string) {
-	data, err := readFamilyTreeFile()
-	if err != nil {
-		fmt.Printf("Error reading family tree file: %v\n", err)
+// removePerson deletes name from the family tree together with every relation
+// other people hold towards them, so no reference is left dangling.
+func removePerson(name string) {
+	familyTree := loadFamilyTree()
+
+	if _, exists := familyTree[name]; !exists {
+		fmt.Printf("%s is not in the family tree.\n", name)
 		os.Exit(1)
 	}
+	delete(familyTree, name)
 
-	var familyTree map[string]Person
-	err = json.Unmarshal(data, &familyTree)
-	if err != nil {
-		fmt.Printf("Error decoding family tree data: %v\n", err)
-		os.Exit(1)
+	scrubbed := 0
+	for other, person := range familyTree {
+		kept := person.Relations[:0]
+		for _, relation := range person.Relations {
+			if relation.Target == name {
+				scrubbed++
+				continue
+			}
+			kept = append(kept, relation)
+		}
+		person.Relations = kept
+		familyTree[other] = person
 	}
+	saveFamilyTree(familyTree)
+
+	fmt.Printf("Removed %s from the family tree", name)
+	if scrubbed > 0 {
+		fmt.Printf(" along with %d %s pointing to them", scrubbed, plural(scrubbed, "relation"))
+	}
+	fmt.Println(".")
+}
+
+// addRelationship records a relationship held by name, given as
+// "add relationship <name> <type> [of <target>]". With a target it links the
+// two people like connect does; without one it stores a bare relation type,
+// which queries cannot follow.
+func addRelationship(name string) {
+	familyTree := loadFamilyTree()
 
 	if _, exists := familyTree[name]; exists {
 		var relation string
@@ -194,21 +958,26 @@ func addRelationship(name string) {
 			os.Exit(1)
 		}
 
-		person := familyTree[name]
-		person.Relations = append(person.Relations, relation)
-		familyTree[name] = person
+		if len(os.Args) >= 7 && os.Args[5] == "of" {
+			target := os.Args[6]
+			if _, exists := familyTree[target]; !exists {
+				fmt.Printf("%s is not in the family tree. You can add the person using 'add person' first.\n", target)
+				os.Exit(1)
+			}
+			if err := addRelation(familyTree, name, target, relation); err != nil {
+				fmt.Printf("Cannot add the relationship: %v.\n", err)
+				os.Exit(1)
+			}
+			saveFamilyTree(familyTree)
 
-		newData, err := json.MarshalIndent(familyTree, "", "  ")
-		if err != nil {
-			fmt.Printf("Error encoding family tree data: %v\n", err)
-			os.Exit(1)
+			fmt.Printf("Added %s as %s of %s.\n", name, relation, target)
+			return
 		}
 
-		err = writeFamilyTreeFile(newData)
-		if err != nil {
-			fmt.Printf("Error writing family tree file: %v\n", err)
-			os.Exit(1)
-		}
+		person := familyTree[name]
+		person.Relations = append(person.Relations, Relation{Type: relation})
+		familyTree[name] = person
+		saveFamilyTree(familyTree)
 
 		fmt.Printf("Added %s as %s's %s.\n", relation, name, relation)
 	} else {
@@ -217,40 +986,18 @@ func addRelationship(name string) {
 }
 
 func connectPeople(name1, relationship, name2 string) {
-	data, err := readFamilyTreeFile()
-	if err != nil {
-		fmt.Printf("Error reading family tree file: %v\n", err)
-		os.Exit(1)
-	}
+	familyTree := loadFamilyTree()
 
-	var familyTree map[string]Person
-	err = json.Unmarshal(data, &familyTree)
-	if err != nil {
-		fmt.Printf("Error decoding family tree data: %v\n", err)
-		os.Exit(1)
-	}
-
-	if person1, exists := familyTree[name1]; exists {
-		if person2, exists := familyTree[name2]; exists {
-			person1.Relations = append(person1.Relations, relationship)
-			familyTree[name1] = person1
-
-			// Add reverse relationship
-			// For example, if Amit Dhakad is a son of KK Dhakad, then KK Dhakad is a parent of Amit Dhakad
-			person2.Relations = append(person2.Relations, "parent")
-			familyTree[name2] = person2
-
-			newData, err := json.MarshalIndent(familyTree, "", "  ")
-			if err != nil {
-				fmt.Printf("Error encoding family tree data: %v\n", err)
+	if _, exists := familyTree[name1]; exists {
+		if _, exists := familyTree[name2]; exists {
+			// addRelation also records the reverse relationship. For example, if
+			// Amit Dhakad is a son of KK Dhakad, then KK Dhakad is a parent of Amit Dhakad
+			if err := addRelation(familyTree, name1, name2, relationship); err != nil {
+				fmt.Printf("Cannot connect %s to %s: %v.\n", name1, name2, err)
 				os.Exit(1)
 			}
 
-			err = writeFamilyTreeFile(newData)
-			if err != nil {
-				fmt.Printf("Error writing family tree file: %v\n", err)
-				os.Exit(1)
-			}
+			saveFamilyTree(familyTree)
 
 			fmt.Printf("Connected %s as %s of %s.\n", name1, relationship, name2)
 		} else {
@@ -261,122 +1008,334 @@ func connectPeople(name1, relationship, name2 string) {
 	}
 }
 
-func countSons(name string) int {
-	data, err := readFamilyTreeFile()
+// countSons, countDaughters, countBrothers, countSisters and countWives count
+// name's relatives of one gender. unknown is the number of those relatives
+// whose gender is not recorded or implied by their relations: they may belong
+// in the count but cannot be placed.
+func countSons(name string, livingOnly bool) (count, unknown int, err error) {
+	return countKin(name, livingOnly, childrenOf, "male")
+}
+
+func countDaughters(name string, livingOnly bool) (count, unknown int, err error) {
+	return countKin(name, livingOnly, childrenOf, "female")
+}
+
+// countChildren counts name's sons and daughters together, including
+// children whose gender is not known.
+func countChildren(name string, livingOnly bool) (count, unknown int, err error) {
+	return countKin(name, livingOnly, childrenOf, "")
+}
+
+// countBrothers and countSisters count siblings linked through shared parents
+// or a sibling relation.
+func countBrothers(name string, livingOnly bool) (count, unknown int, err error) {
+	return countKin(name, livingOnly, siblingsOf, "male")
+}
+
+func countSisters(name string, livingOnly bool) (count, unknown int, err error) {
+	return countKin(name, livingOnly, siblingsOf, "female")
+}
+
+func countWives(name string, livingOnly bool) (count, unknown int, err error) {
+	return countKin(name, livingOnly, spousesOf, "female")
+}
+
+// printKinCount prints the result of a count function such as countSons for
+// name. With --living only relatives without a death date are counted, and
+// --verbose adds the total alongside. Relatives left out because their gender
+// is unknown are mentioned rather than silently dropped.
+func printKinCount(name, word string, count func(string, bool) (int, int, error), args []string) {
+	living := hasFlag(args, "--living")
+	n, unknown, err := count(name, living)
 	if err != nil {
-		fmt.Printf("Error reading family tree file: %v\n", err)
+		fmt.Printf("%v.\n", err)
 		os.Exit(1)
 	}
+	if !living {
+		fmt.Printf("%s has %d %s.\n", name, n, plural(n, word))
+	} else {
+		fmt.Printf("%s has %d living %s", name, n, plural(n, word))
+		if hasFlag(args, "--verbose") {
+			total, _, _ := count(name, false)
+			fmt.Printf(" (%d in total)", total)
+		}
+		fmt.Println(".")
+	}
+	if unknown == 1 {
+		fmt.Println("Note: 1 relative of unknown gender was not counted; use 'set gender' to record it.")
+	} else if unknown > 1 {
+		fmt.Printf("Note: %d relatives of unknown gender were not counted; use 'set gender' to record them.\n", unknown)
+	}
+}
 
-	var familyTree map[string]Person
-	err = json.Unmarshal(data, &familyTree)
-	if err != nil {
-		fmt.Printf("Error decoding family tree data: %v\n", err)
-		os.Exit(1)
+// countKin counts the relatives of name found by kin who have the given
+// gender, or all of them when gender is "". Only those without a death date
+// are counted when livingOnly is set. unknown counts the relatives skipped
+// because their gender is unknown.
+func countKin(name string, livingOnly bool, kin func(map[string]Person, string) []string, gender string) (count, unknown int, err error) {
+	familyTree := loadFamilyTree()
+
+	if _, exists := familyTree[name]; !exists {
+		return 0, 0, personNotFound(name)
 	}
 
-	if person, exists := familyTree[name]; exists {
-		count := 0
-		for _, relation := range person.Relations {
-			if relation == "son" {
-				count++
-			}
+	for _, relative := range kin(familyTree, name) {
+		if livingOnly && familyTree[relative].Death != "" {
+			continue
+		}
+		switch relativeGender := genderOf(familyTree, relative); {
+		case gender == "" || relativeGender == gender:
+			count++
+		case relativeGender == "unknown":
+			unknown++
 		}
-		return count
 	}
+	return count, unknown, nil
+}
 
-	fmt.Printf("%s is not in the family tree.\n", name)
-	os.Exit(1)
-	return 0
+// findFather returns the father of name: the recorded parent who holds a
+// "father" relation towards them or, failing that, the one who is male. Links
+// stored on the child ("son of") and on the father ("father of") both count,
+// since each relation names its Target. It returns "" when no father is
+// recorded.
+func findFather(name string) string {
+	return fatherOf(loadFamilyTree(), name)
 }
 
-func countDaughters(name string) int {
-	data, err := readFamilyTreeFile()
-	if err != nil {
-		fmt.Printf("Error reading family tree file: %v\n", err)
+// findMother returns the mother of name: the recorded parent who is female,
+// whether the link is stored on the child or on the mother. It returns ""
+// when no mother is recorded.
+func findMother(name string) string {
+	return motherOf(loadFamilyTree(), name)
+}
+
+// printParentJSON prints {"person": name, role: parent} for scripts, with
+// null standing for no recorded parent so it is not mistaken for an error.
+// A person missing from the tree is still an error.
+func printParentJSON(name, role, parent string) {
+	if _, exists := loadFamilyTree()[name]; !exists {
+		fmt.Printf("%s is not in the family tree.\n", name)
 		os.Exit(1)
 	}
 
-	var familyTree map[string]Person
-	err = json.Unmarshal(data, &familyTree)
-	if err != nil {
-		fmt.Printf("Error decoding family tree data: %v\n", err)
-		os.Exit(1)
+	person, _ := json.Marshal(name)
+	value := []byte("null")
+	if parent != "" {
+		value, _ = json.Marshal(parent)
 	}
+	fmt.Printf("{\"person\":%s,\"%s\":%s}\n", person, role, value)
+}
 
-	if person, exists := familyTree[name]; exists {
-		count := 0
-		for _, relation := range person.Relations {
-			if relation == "daughter" {
-				count++
-			}
+func branching() {
+	familyTree := loadFamilyTree()
+
+	// histogram maps a number of children to how many people have that many.
+	histogram := make(map[int]int)
+	parents, total, most := 0, 0, 0
+	for name := range familyTree {
+		children := len(childrenOf(familyTree, name))
+		histogram[children]++
+		if children > 0 {
+			parents++
+			total += children
+		}
+		if children > most {
+			most = children
 		}
-		return count
 	}
 
-	fmt.Printf("%s is not in the family tree.\n", name)
-	os.Exit(1)
-	return 0
+	if parents == 0 {
+		fmt.Println("No one in the family tree has children recorded.")
+		return
+	}
+
+	fmt.Printf("Parents: %d\n", parents)
+	fmt.Printf("Average children per parent: %.2f\n", float64(total)/float64(parents))
+	fmt.Printf("Maximum children: %d\n", most)
+	fmt.Println("\nChildren  People")
+	for children := 0; children <= most; children++ {
+		fmt.Printf("%8d  %s %d\n", children, strings.Repeat("#", histogram[children]), histogram[children])
+	}
 }
 
-func countWives(name string) int {
-	data, err := readFamilyTreeFile()
-	if err != nil {
-		fmt.Printf("Error reading family tree file: %v\n", err)
-		os.Exit(1)
+// listPeople prints everyone in the tree in alphabetical order with the number
+// of relations they hold, and each relation underneath when showRelations is
+// set.
+func listPeople(showRelations bool) {
+	familyTree := loadFamilyTree()
+
+	if len(familyTree) == 0 {
+		fmt.Println("The family tree is empty.")
+		return
 	}
 
-	var familyTree map[string]Person
-	err = json.Unmarshal(data, &familyTree)
-	if err != nil {
-		fmt.Printf("Error decoding family tree data: %v\n", err)
+	for _, name := range sortedNames(familyTree) {
+		relations := familyTree[name].Relations
+		fmt.Printf("%s (%d %s)\n", name, len(relations), plural(len(relations), "relation"))
+		if !showRelations {
+			continue
+		}
+		for _, relation := range relations {
+			if relation.Target != "" {
+				fmt.Printf("  %s of %s\n", relation.Type, relation.Target)
+			} else {
+				fmt.Printf("  %s\n", relation.Type)
+			}
+		}
+	}
+}
+
+func showPerson(name string) {
+	familyTree := loadFamilyTree()
+
+	person, exists := familyTree[name]
+	if !exists {
+		fmt.Printf("%s is not in the family tree.\n", name)
 		os.Exit(1)
 	}
 
-	if person, exists := familyTree[name]; exists {
-		count := 0
-		for _, relation := range person.Relations {
-			if relation == "wife" {
-				count++
-			}
+	fmt.Printf("Name: %s\n", person.Name)
+	if person.Gender != "" {
+		fmt.Printf("Gender: %s\n", person.Gender)
+	}
+	if person.Birth != "" {
+		fmt.Printf("Born: %s\n", displayDate(person.Birth))
+	}
+	if person.Death != "" {
+		fmt.Printf("Died: %s\n", displayDate(person.Death))
+	}
+	if len(person.Tags) > 0 {
+		fmt.Printf("Tags: %s\n", strings.Join(person.Tags, ", "))
+	}
+	for _, source := range person.Sources {
+		fmt.Printf("Source: %s\n", source)
+	}
+	if person.Photo != "" {
+		fmt.Printf("Photo: %s\n", person.Photo)
+	}
+	if len(person.Relations) == 0 {
+		fmt.Println("Relations: none")
+		return
+	}
+	fmt.Println("Relations:")
+	for _, relation := range person.Relations {
+		if relation.Target != "" {
+			fmt.Printf("  %s of %s\n", relation.Type, relation.Target)
+		} else {
+			fmt.Printf("  %s\n", relation.Type)
 		}
-		return count
+	}
+}
+
+func verifyReciprocity(fix bool) {
+	familyTree := loadFamilyTree()
+
+	missing := reciprocityGaps(familyTree)
+	if len(missing) == 0 {
+		fmt.Println("All relationships are reciprocal.")
+		return
+	}
+
+	for _, link := range missing {
+		fmt.Printf("%s is %s of %s, but %s does not list %s as %s.\n",
+			link.holder, link.relationType, link.target, link.target, link.holder, inverseRelation(link.relationType))
+	}
+
+	if !fix {
+		fmt.Printf("\n%d relationships are missing their reciprocal. Run with --fix to add them.\n", len(missing))
+		return
 	}
 
-	fmt.Printf("%s is not in the family tree.\n", name)
-	os.Exit(1)
-	return 0
+	fixed := 0
+	for _, link := range missing {
+		if err := addRelation(familyTree, link.holder, link.target, link.relationType); err != nil {
+			fmt.Printf("Cannot add the reciprocal of %s's %s relation to %s: %v.\n", link.holder, link.relationType, link.target, err)
+			continue
+		}
+		fixed++
+	}
+	if fixed == 0 {
+		fmt.Println("\nNo reciprocal relationships could be added.")
+		os.Exit(1)
+	}
+	saveFamilyTree(familyTree)
+	fmt.Printf("\nAdded %d missing reciprocal %s.\n", fixed, plural(fixed, "relationship"))
+	if fixed < len(missing) {
+		fmt.Printf("%d could not be added.\n", len(missing)-fixed)
+		os.Exit(1)
+	}
 }
 
-func findFather(name string) string {
+// loadFamilyTree reads and decodes the family tree file, migrating it to the
+// current schema if needed. While running a batch of commands it returns the
+// tree loaded at the start of the batch instead.
+func loadFamilyTree() map[string]Person {
+	if batch != nil {
+		return batch.familyTree
+	}
+
+	start := time.Now()
+	defer func() { loadTime += time.Since(start) }()
+
 	data, err := readFamilyTreeFile()
 	if err != nil {
 		fmt.Printf("Error reading family tree file: %v\n", err)
 		os.Exit(1)
 	}
 
-	var familyTree map[string]Person
-	err = json.Unmarshal(data, &familyTree)
+	familyTree, err := decodeFamilyTree(data)
 	if err != nil {
 		fmt.Printf("Error decoding family tree data: %v\n", err)
 		os.Exit(1)
 	}
+	if rootScope != "" {
+		familyTree = scopeToFamily(familyTree, rootScope)
+	}
+	return familyTree
+}
 
-	if person, exists := familyTree[name]; exists {
-		for _, relation := range person.Relations {
-			if relation == "father" {
-				// Search for the father's name in the family tree
-				for key, value := range familyTree {
-					if key != name && value.Name == person.Name {
-						return key
-					}
-				}
-			}
-		}
+// checkWritable refuses to go on when the family tree file must not be
+// written: with --read-only, or with --root, where only one family is loaded
+// and saving would drop every other family from the file. Every path that
+// writes the file or backs it up before writing calls this, so commands that
+// only write with an option such as --fix are covered without being listed
+// in mutatingCommands.
+func checkWritable() {
+	if readOnly {
+		fmt.Printf("Command '%s' modifies the family tree and is blocked by --read-only.\n", os.Args[1])
+		os.Exit(1)
+	}
+	if rootScope != "" {
+		fmt.Printf("Command '%s' modifies the family tree and cannot be scoped with --root.\n", os.Args[1])
+		os.Exit(1)
+	}
+}
+
+// saveFamilyTree encodes the family tree in the current schema and writes it.
+// While running a batch of commands the write is deferred to the end of the
+// batch.
+func saveFamilyTree(familyTree map[string]Person) {
+	checkWritable()
+	if batch != nil {
+		batch.familyTree = familyTree
+		batch.modified = true
+		return
+	}
+
+	start := time.Now()
+	defer func() { saveTime += time.Since(start) }()
+
+	data, err := encodeFamilyTree(familyTree)
+	if err != nil {
+		fmt.Printf("Error encoding family tree data: %v\n", err)
+		os.Exit(1)
 	}
 
-	// If no father is found, return an empty string
-	return ""
+	err = writeFamilyTreeFile(data)
+	if err != nil {
+		fmt.Printf("Error writing family tree file: %v\n", err)
+		os.Exit(1)
+	}
 }
 
 func readFamilyTreeFile() ([]byte, error) {
@@ -398,10 +1357,55 @@ func readFamilyTreeFile() ([]byte, error) {
 		}
 		data = append(data, buffer[:n]...)
 	}
+
+	// Encrypted files are decrypted transparently and stay encrypted when
+	// written back.
+	if isEncrypted(data) {
+		encryptOnWrite = true
+		data, err = decryptData(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Compressed files are recognised by the gzip magic number rather than
+	// the extension, so a renamed file still loads.
+	if bytes.HasPrefix(data, gzipMagic) {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		return io.ReadAll(reader)
+	}
 	return data, nil
 }
 
 func writeFamilyTreeFile(data []byte) error {
+	if readOnly {
+		return errReadOnly
+	}
+
+	if strings.HasSuffix(familyTreeFile, ".gz") {
+		var compressed bytes.Buffer
+		writer := gzip.NewWriter(&compressed)
+		if _, err := writer.Write(data); err != nil {
+			return err
+		}
+		if err := writer.Close(); err != nil {
+			return err
+		}
+		data = compressed.Bytes()
+	}
+
+	if encryptOnWrite {
+		encrypted, err := encryptData(data)
+		if err != nil {
+			return err
+		}
+		data = encrypted
+	}
+
 	file, err := os.Create(familyTreeFile)
 	if err != nil {
 		return err
@@ -411,4 +1415,3 @@ func writeFamilyTreeFile(data []byte) error {
 	_, err = file.Write(data)
 	return err
 }
-
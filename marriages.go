@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// marriage is one of a person's marriages. Marriage and divorce dates are
+// not recorded, so a marriage counts as ended only by the first recorded
+// death of the two partners: endedBy died on ended.
+type marriage struct {
+	spouse         string
+	ended, endedBy string
+}
+
+// marriagesOf returns name's marriages in order: the ended ones first,
+// earliest end first, then the current ones by spouse name.
+// Each marriage appears once however many of the two partners record it.
+func marriagesOf(familyTree map[string]Person, name string) []marriage {
+	var marriages []marriage
+	for _, spouse := range spousesOf(familyTree, name) {
+		m := marriage{spouse: spouse}
+		for _, partner := range []string{name, spouse} {
+			death, ok := personDate(familyTree[partner].Death)
+			if !ok {
+				continue
+			}
+			if end, ended := personDate(m.ended); !ended || certainlyBefore(death, end) {
+				m.ended, m.endedBy = familyTree[partner].Death, partner
+			}
+		}
+		marriages = append(marriages, m)
+	}
+	sort.SliceStable(marriages, func(i, j int) bool {
+		a, aEnded := personDate(marriages[i].ended)
+		b, bEnded := personDate(marriages[j].ended)
+		if aEnded != bEnded {
+			return aEnded
+		}
+		return aEnded && a.earliest().Before(b.earliest())
+	})
+	return marriages
+}
+
+// describeMarriage formats a marriage for the marriage reports.
+func describeMarriage(m marriage) string {
+	if m.ended != "" {
+		return fmt.Sprintf("%s (former; ended by %s's death, %s)", m.spouse, m.endedBy, displayDate(m.ended))
+	}
+	return fmt.Sprintf("%s (current)", m.spouse)
+}
+
+func countMarriages(name string) {
+	familyTree := loadFamilyTree()
+
+	if _, exists := familyTree[name]; !exists {
+		fmt.Printf("%s is not in the family tree.\n", name)
+		os.Exit(1)
+	}
+
+	marriages := marriagesOf(familyTree, name)
+	current := 0
+	for _, m := range marriages {
+		if m.ended == "" {
+			current++
+		}
+	}
+	fmt.Printf("%s has %d %s (%d current, %d former).\n", name, len(marriages), plural(len(marriages), "marriage"), current, len(marriages)-current)
+	for _, m := range marriages {
+		fmt.Printf("  %s\n", describeMarriage(m))
+	}
+}
+
+// printRemarriages lists everyone married more than once, with their
+// marriages in order.
+func printRemarriages() {
+	familyTree := loadFamilyTree()
+
+	found := 0
+	for _, name := range sortedNames(familyTree) {
+		marriages := marriagesOf(familyTree, name)
+		if len(marriages) < 2 {
+			continue
+		}
+		fmt.Printf("%s (%d marriages):\n", name, len(marriages))
+		for _, m := range marriages {
+			fmt.Printf("  %s\n", describeMarriage(m))
+		}
+		found++
+	}
+	if found == 0 {
+		fmt.Println("No one is recorded as married more than once.")
+	}
+}
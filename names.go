@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// nameOrder says where the family name goes in a full name: "given-first"
+// for "Amit Sharma", or "family-first" for "Sharma Amit" as in Chinese or
+// Hungarian names. It is set with --name-order.
+var nameOrder = "given-first"
+
+// splitName splits a full name into its given names and family name
+// according to nameOrder. The family name is the last word, or the first
+// word for family-first names. A single-word name has no family name.
+func splitName(name string) (given, family string) {
+	words := strings.Fields(name)
+	if len(words) < 2 {
+		return strings.Join(words, " "), ""
+	}
+	if nameOrder == "family-first" {
+		return strings.Join(words[1:], " "), words[0]
+	}
+	return strings.Join(words[:len(words)-1], " "), words[len(words)-1]
+}
+
+// surname returns the family name part of a full name.
+func surname(name string) string {
+	_, family := splitName(name)
+	return family
+}
+
+// withSurname returns name with its surname replaced by newSurname.
+func withSurname(name, newSurname string) string {
+	given, _ := splitName(name)
+	if nameOrder == "family-first" {
+		return newSurname + " " + given
+	}
+	return given + " " + newSurname
+}
+
+// renamePerson moves a person to a new name and updates every relation that
+// points at them.
+func renamePerson(familyTree map[string]Person, oldName, newName string) {
+	person := familyTree[oldName]
+	delete(familyTree, oldName)
+	person.Name = newName
+	familyTree[newName] = person
+
+	for name, other := range familyTree {
+		changed := false
+		for i, relation := range other.Relations {
+			if relation.Target == oldName {
+				other.Relations[i].Target = newName
+				changed = true
+			}
+		}
+		if changed {
+			familyTree[name] = other
+		}
+	}
+}
+
+// renameSurname changes the surname oldSurname to newSurname for everyone in
+// the tree, or only for under and their descendants when under is set.
+func renameSurname(oldSurname, newSurname, under string) {
+	familyTree := loadFamilyTree()
+
+	scope := sortedNames(familyTree)
+	if under != "" {
+		if _, exists := familyTree[under]; !exists {
+			fmt.Printf("%s is not in the family tree.\n", under)
+			os.Exit(1)
+		}
+		scope = []string{under}
+		for descendant := range descendantDepths(familyTree, under) {
+			scope = append(scope, descendant)
+		}
+	}
+
+	renames := make(map[string]string)
+	for _, name := range scope {
+		if surname(name) != oldSurname {
+			continue
+		}
+		newName := withSurname(name, newSurname)
+		if _, exists := familyTree[newName]; exists {
+			fmt.Printf("Skipping %s: %s is already in the family tree.\n", name, newName)
+			continue
+		}
+		renames[name] = newName
+	}
+
+	if len(renames) == 0 {
+		fmt.Printf("No one with the surname %s was found.\n", oldSurname)
+		return
+	}
+
+	backup, err := backupFamilyTreeFile()
+	if err != nil {
+		fmt.Printf("Error backing up family tree file: %v\n", err)
+		os.Exit(1)
+	}
+
+	for oldName, newName := range renames {
+		renamePerson(familyTree, oldName, newName)
+	}
+	saveFamilyTree(familyTree)
+
+	fmt.Printf("Renamed %d %s from %s to %s. Backup saved to %s.\n", len(renames), plural(len(renames), "person"), oldSurname, newSurname, backup)
+}
+
+// printSurnames prints how many people share each surname, most common first.
+func printSurnames() {
+	familyTree := loadFamilyTree()
+
+	const unknown = "(unknown)"
+	counts := make(map[string]int)
+	for name := range familyTree {
+		family := surname(name)
+		if family == "" {
+			family = unknown
+		}
+		counts[family]++
+	}
+
+	if len(counts) == 0 {
+		fmt.Println("The family tree is empty.")
+		return
+	}
+
+	surnames := make([]string, 0, len(counts))
+	for family := range counts {
+		surnames = append(surnames, family)
+	}
+	sort.Slice(surnames, func(i, j int) bool {
+		if counts[surnames[i]] != counts[surnames[j]] {
+			return counts[surnames[i]] > counts[surnames[j]]
+		}
+		return surnames[i] < surnames[j]
+	})
+
+	for _, family := range surnames {
+		fmt.Printf("%-20s %d\n", family, counts[family])
+	}
+	noteSeparateFamilies(familyTree)
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// typeSynonyms maps relation types seen in imported data to the vocabulary
+// the rest of the program understands.
+var typeSynonyms = map[string]string{
+	"partner":   "spouse",
+	"consort":   "spouse",
+	"dad":       "father",
+	"papa":      "father",
+	"mom":       "mother",
+	"mum":       "mother",
+	"mama":      "mother",
+	"kid":       "child",
+	"offspring": "child",
+	"bro":       "brother",
+	"sis":       "sister",
+}
+
+// normalizeTypes rewrites relation types across the whole tree using
+// typeSynonyms together with extra, which takes precedence. Types are also
+// lower-cased, so "Son" becomes "son". Relations that become duplicates are
+// dropped.
+func normalizeTypes(extra map[string]string) {
+	synonyms := make(map[string]string)
+	for from, to := range typeSynonyms {
+		synonyms[from] = to
+	}
+	for from, to := range extra {
+		if inverseRelation(to) == "" {
+			fmt.Printf("Cannot map '%s' to '%s': '%s' is not a known relationship type.\n", from, to, to)
+			os.Exit(1)
+		}
+		synonyms[strings.ToLower(from)] = to
+	}
+
+	familyTree := loadFamilyTree()
+
+	changed := make(map[string]int)
+	for name, person := range familyTree {
+		var relations []Relation
+		for _, relation := range person.Relations {
+			canonical := strings.ToLower(strings.TrimSpace(relation.Type))
+			if to, ok := synonyms[canonical]; ok {
+				canonical = to
+			}
+			if canonical != relation.Type {
+				changed[relation.Type+" -> "+canonical]++
+				relation.Type = canonical
+			}
+			if !containsRelation(relations, relation) {
+				relations = append(relations, relation)
+			}
+		}
+		person.Relations = relations
+		familyTree[name] = person
+	}
+
+	if len(changed) == 0 {
+		fmt.Println("All relationship types are already canonical.")
+		return
+	}
+	saveFamilyTree(familyTree)
+
+	rewrites := make([]string, 0, len(changed))
+	total := 0
+	for rewrite, count := range changed {
+		rewrites = append(rewrites, rewrite)
+		total += count
+	}
+	sort.Strings(rewrites)
+	for _, rewrite := range rewrites {
+		fmt.Printf("  %-25s %d\n", rewrite, changed[rewrite])
+	}
+	fmt.Printf("Rewrote %d %s.\n", total, plural(total, "relation"))
+}
+
+func containsRelation(relations []Relation, relation Relation) bool {
+	for _, existing := range relations {
+		if existing == relation {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTypeMap reads the repeatable "--map from=to" options of normalize-types.
+func parseTypeMap(args []string) map[string]string {
+	mapping := make(map[string]string)
+	for i := 0; i < len(args); i++ {
+		if args[i] != "--map" {
+			continue
+		}
+		if i+1 >= len(args) {
+			fmt.Println("The --map option requires a from=to pair.")
+			os.Exit(1)
+		}
+		i++
+		from, to, found := strings.Cut(args[i], "=")
+		if !found || from == "" || to == "" {
+			fmt.Printf("Invalid mapping '%s'; use from=to, e.g. partner=spouse.\n", args[i])
+			os.Exit(1)
+		}
+		mapping[from] = strings.ToLower(to)
+	}
+	return mapping
+}
+
+// normalizeFile tidies the whole family tree in one pass: names lose stray
+// whitespace, the name stored with each person matches their key, relation
+// fields are trimmed and duplicates dropped, and the file is rewritten in the
+// current schema with people and relations in canonical order. A backup is
+// taken first.
+func normalizeFile() {
+	var version int
+	if batch == nil {
+		if data, err := readFamilyTreeFile(); err == nil {
+			version, _ = schemaVersion(data)
+		}
+	}
+	familyTree := loadFamilyTree()
+
+	renamed, nameFields, trimmed, duplicates := 0, 0, 0, 0
+	for _, name := range sortedNames(familyTree) {
+		clean := strings.Join(strings.Fields(name), " ")
+		if clean == name {
+			continue
+		}
+		if _, taken := familyTree[clean]; taken {
+			fmt.Printf("Cannot rename '%s' to '%s': that name is already in use.\n", name, clean)
+			continue
+		}
+		renamePerson(familyTree, name, clean)
+		renamed++
+	}
+	for name, person := range familyTree {
+		if person.Name != name {
+			person.Name = name
+			nameFields++
+		}
+		var relations []Relation
+		for _, relation := range person.Relations {
+			clean := Relation{Type: strings.TrimSpace(relation.Type), Target: strings.Join(strings.Fields(relation.Target), " ")}
+			if clean != relation {
+				trimmed++
+			}
+			if containsRelation(relations, clean) {
+				duplicates++
+				continue
+			}
+			relations = append(relations, clean)
+		}
+		if relations == nil {
+			relations = []Relation{}
+		}
+		person.Relations = relations
+		familyTree[name] = person
+	}
+
+	backup, err := backupFamilyTreeFile()
+	if err != nil {
+		fmt.Printf("Error backing up family tree file: %v\n", err)
+		os.Exit(1)
+	}
+	saveFamilyTree(familyTree)
+
+	fmt.Printf("Names with stray whitespace fixed:  %d\n", renamed)
+	fmt.Printf("Stored names matched to keys:       %d\n", nameFields)
+	fmt.Printf("Relations trimmed:                  %d\n", trimmed)
+	fmt.Printf("Duplicate relations removed:        %d\n", duplicates)
+	if version != 0 && version < currentSchemaVersion {
+		fmt.Printf("Schema upgraded from version %d to %d.\n", version, currentSchemaVersion)
+	}
+	fmt.Printf("People and relations are now in canonical order. Backup saved to %s.\n", backup)
+}
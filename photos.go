@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var imageExtensions = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true,
+	".tif": true, ".tiff": true, ".webp": true, ".bmp": true,
+}
+
+// Photos whose file name is at least autoMatch similar to a person's name are
+// matched without asking; down to askMatch the user is asked to confirm, and
+// anything less similar is left unmatched.
+const (
+	autoMatch = 0.9
+	askMatch  = 0.6
+)
+
+// importPhotos matches the image files in dir to people by file name, e.g.
+// "amit_sharma.jpg" to "Amit Sharma", and records each match as that
+// person's photo. Close but inexact matches are confirmed first.
+func importPhotos(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		fmt.Printf("Error reading photo directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	familyTree := loadFamilyTree()
+	names := sortedNames(familyTree)
+
+	var matched, unmatched []string
+	for _, entry := range entries {
+		extension := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || !imageExtensions[extension] {
+			continue
+		}
+		stem := normalizeForMatch(strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())))
+
+		best, bestScore := "", 0.0
+		for _, name := range names {
+			if score := similarity(stem, normalizeForMatch(name)); score > bestScore {
+				best, bestScore = name, score
+			}
+		}
+
+		switch {
+		case bestScore >= autoMatch:
+		case bestScore >= askMatch && confirm(fmt.Sprintf("Use %s as the photo of %s?", entry.Name(), best)):
+		default:
+			unmatched = append(unmatched, entry.Name())
+			continue
+		}
+
+		person := familyTree[best]
+		person.Photo = filepath.Join(dir, entry.Name())
+		familyTree[best] = person
+		matched = append(matched, fmt.Sprintf("%s -> %s", entry.Name(), best))
+	}
+
+	if len(matched) > 0 {
+		saveFamilyTree(familyTree)
+		fmt.Printf("Matched %d %s:\n", len(matched), plural(len(matched), "photo"))
+		for _, match := range matched {
+			fmt.Printf("  %s\n", match)
+		}
+	}
+	if len(unmatched) > 0 {
+		sort.Strings(unmatched)
+		fmt.Printf("Unmatched %d %s:\n", len(unmatched), plural(len(unmatched), "photo"))
+		for _, file := range unmatched {
+			fmt.Printf("  %s\n", file)
+		}
+	}
+	if len(matched) == 0 && len(unmatched) == 0 {
+		fmt.Printf("No image files found in %s.\n", dir)
+	}
+}
+
+// normalizeForMatch lower-cases s and turns the separators common in file
+// names into single spaces.
+func normalizeForMatch(s string) string {
+	s = strings.ToLower(s)
+	s = strings.NewReplacer("_", " ", "-", " ", ".", " ").Replace(s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// similarity scores how alike a and b are, from 0 for nothing in common to
+// 1 for identical, based on their edit distance.
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(editDistance(ra, rb))/float64(longest)
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = previous[j-1] + cost
+			if previous[j]+1 < current[j] {
+				current[j] = previous[j] + 1
+			}
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
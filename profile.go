@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"time"
+)
+
+// profileKind is "cpu" or "mem" when --profile asks for a pprof profile of
+// the command, and showTiming is set by --timing.
+var (
+	profileKind string
+	showTiming  bool
+)
+
+// Time spent loading and saving the family tree, for --timing.
+var loadTime, saveTime time.Duration
+
+// startProfile begins the profile requested with --profile and returns the
+// function that finishes it.
+func startProfile() (stop func()) {
+	if profileKind == "" {
+		return func() {}
+	}
+
+	path := fmt.Sprintf("family-tree.%s.pprof", profileKind)
+	file, err := os.Create(path)
+	if err != nil {
+		fmt.Printf("Error creating profile: %v\n", err)
+		os.Exit(1)
+	}
+
+	if profileKind == "cpu" {
+		if err := pprof.StartCPUProfile(file); err != nil {
+			fmt.Printf("Error starting CPU profile: %v\n", err)
+			os.Exit(1)
+		}
+		return func() {
+			pprof.StopCPUProfile()
+			file.Close()
+			fmt.Fprintf(os.Stderr, "CPU profile written to %s.\n", path)
+		}
+	}
+	return func() {
+		runtime.GC()
+		if err := pprof.WriteHeapProfile(file); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing memory profile: %v\n", err)
+		}
+		file.Close()
+		fmt.Fprintf(os.Stderr, "Memory profile written to %s.\n", path)
+	}
+}
+
+// reportTiming prints, for --timing, how the time since start was split
+// between loading, computing and saving.
+func reportTiming(start time.Time) {
+	if !showTiming {
+		return
+	}
+	total := time.Since(start)
+	fmt.Fprintf(os.Stderr, "Load:    %s\n", loadTime.Round(time.Microsecond))
+	fmt.Fprintf(os.Stderr, "Compute: %s\n", (total - loadTime - saveTime).Round(time.Microsecond))
+	fmt.Fprintf(os.Stderr, "Save:    %s\n", saveTime.Round(time.Microsecond))
+	fmt.Fprintf(os.Stderr, "Total:   %s\n", total.Round(time.Microsecond))
+}
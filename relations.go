@@ -0,0 +1,538 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// reciprocals maps a relation type to the types the other person may hold to
+// record the same link from their side. The first entry is the one added when
+// a missing reciprocal is repaired.
+var reciprocals = map[string][]string{
+	"son":      {"parent", "father", "mother"},
+	"daughter": {"parent", "father", "mother"},
+	"child":    {"parent", "father", "mother"},
+	"father":   {"child", "son", "daughter"},
+	"mother":   {"child", "son", "daughter"},
+	"parent":   {"child", "son", "daughter"},
+	"wife":     {"spouse", "husband", "wife"},
+	"husband":  {"spouse", "wife", "husband"},
+	"spouse":   {"spouse", "wife", "husband"},
+	"brother":  {"sibling", "brother", "sister"},
+	"sister":   {"sibling", "brother", "sister"},
+	"sibling":  {"sibling", "brother", "sister"},
+}
+
+// inverseRelation returns the relation type the target of a relationType
+// relation should hold back towards its holder, such as parent for son or
+// spouse for wife, or "" for unknown types.
+func inverseRelation(relationType string) string {
+	if types, ok := reciprocals[relationType]; ok {
+		return types[0]
+	}
+	return ""
+}
+
+// knownRelationTypes returns the sorted relation types inverseRelation knows.
+func knownRelationTypes() []string {
+	types := make([]string, 0, len(reciprocals))
+	for relationType := range reciprocals {
+		types = append(types, relationType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// hasReciprocal reports whether target records a relation back to holder that
+// matches relationType.
+func hasReciprocal(familyTree map[string]Person, holder string, relationType string, target string) bool {
+	for _, relation := range familyTree[target].Relations {
+		if relation.Target != holder {
+			continue
+		}
+		for _, accepted := range reciprocals[relationType] {
+			if relation.Type == accepted {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sortedNames returns the keys of the family tree in alphabetical order.
+func sortedNames(familyTree map[string]Person) []string {
+	names := make([]string, 0, len(familyTree))
+	for name := range familyTree {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// childTypes are the relation types a child holds towards a parent, and
+// parentTypes the ones a parent holds towards a child.
+var (
+	childTypes  = map[string]bool{"son": true, "daughter": true, "child": true}
+	parentTypes = map[string]bool{"father": true, "mother": true, "parent": true}
+)
+
+// parentsOf returns the sorted names of the recorded parents of name, whether
+// the link was stored on the child or on the parent.
+func parentsOf(familyTree map[string]Person, name string) []string {
+	parents := make(map[string]bool)
+	for _, relation := range familyTree[name].Relations {
+		if childTypes[relation.Type] {
+			parents[relation.Target] = true
+		}
+	}
+	for other, person := range familyTree {
+		for _, relation := range person.Relations {
+			if parentTypes[relation.Type] && relation.Target == name {
+				parents[other] = true
+			}
+		}
+	}
+	return existingNames(familyTree, parents, name)
+}
+
+// childrenOf returns the sorted names of the recorded children of name.
+func childrenOf(familyTree map[string]Person, name string) []string {
+	children := make(map[string]bool)
+	for _, relation := range familyTree[name].Relations {
+		if parentTypes[relation.Type] {
+			children[relation.Target] = true
+		}
+	}
+	for other, person := range familyTree {
+		for _, relation := range person.Relations {
+			if childTypes[relation.Type] && relation.Target == name {
+				children[other] = true
+			}
+		}
+	}
+	return existingNames(familyTree, children, name)
+}
+
+// existingNames returns the sorted members of set that are in the family tree,
+// leaving out self and empty targets.
+func existingNames(familyTree map[string]Person, set map[string]bool, self string) []string {
+	var names []string
+	for name := range set {
+		if _, exists := familyTree[name]; exists && name != self {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ancestorsAtLevel returns the sorted names of the ancestors exactly level
+// generations above name: 1 for parents, 2 for grandparents and so on.
+func ancestorsAtLevel(familyTree map[string]Person, name string, level int) []string {
+	return walkLevels(familyTree, name, level, parentsOf)
+}
+
+// descendantsAtLevel returns the sorted names of the descendants exactly level
+// generations below name.
+func descendantsAtLevel(familyTree map[string]Person, name string, level int) []string {
+	return walkLevels(familyTree, name, level, childrenOf)
+}
+
+// walkLevels follows step level times from name, but no further than
+// maxGenerations. Each level is a set, so cycles in malformed data cannot
+// make it run away.
+func walkLevels(familyTree map[string]Person, name string, level int, step func(map[string]Person, string) []string) []string {
+	if beyondGenerationLimit(level) {
+		return nil
+	}
+	current := map[string]bool{name: true}
+	for i := 0; i < level; i++ {
+		next := make(map[string]bool)
+		for person := range current {
+			for _, relative := range step(familyTree, person) {
+				next[relative] = true
+			}
+		}
+		current = next
+	}
+	return existingNames(familyTree, current, "")
+}
+
+// cousinsOf returns the sorted names of the degree-th cousins of name: people
+// of the same generation whose nearest shared ancestors are degree+1
+// generations up. Degree 1 gives first cousins.
+func cousinsOf(familyTree map[string]Person, name string, degree int) []string {
+	// Anyone sharing a closer ancestor is a sibling or a nearer cousin.
+	closer := make(map[string]bool)
+	for _, ancestor := range ancestorsAtLevel(familyTree, name, degree) {
+		for _, relative := range descendantsAtLevel(familyTree, ancestor, degree) {
+			closer[relative] = true
+		}
+	}
+
+	cousins := make(map[string]bool)
+	for _, ancestor := range ancestorsAtLevel(familyTree, name, degree+1) {
+		for _, relative := range descendantsAtLevel(familyTree, ancestor, degree+1) {
+			if !closer[relative] {
+				cousins[relative] = true
+			}
+		}
+	}
+	return existingNames(familyTree, cousins, name)
+}
+
+// neighbors returns the sorted names of everyone directly linked to name by a
+// relation in either direction.
+func neighbors(familyTree map[string]Person, name string) []string {
+	linked := make(map[string]bool)
+	for _, relation := range familyTree[name].Relations {
+		linked[relation.Target] = true
+	}
+	for other, person := range familyTree {
+		for _, relation := range person.Relations {
+			if relation.Target == name {
+				linked[other] = true
+			}
+		}
+	}
+	return existingNames(familyTree, linked, name)
+}
+
+// distancesFrom returns the number of relation hops from name to everyone
+// reachable from them, found breadth-first. name itself is at distance 0.
+func distancesFrom(familyTree map[string]Person, name string) map[string]int {
+	return distancesWithin(familyTree, name, -1)
+}
+
+// distancesWithin is distancesFrom stopping at hops relation hops, or never
+// when hops is negative.
+func distancesWithin(familyTree map[string]Person, name string, hops int) map[string]int {
+	distance, _ := routesWithin(familyTree, name, hops)
+	return distance
+}
+
+// routesWithin is distancesWithin also returning, for everyone reached, the
+// person they were reached from, so routeTo can rebuild the path followed.
+func routesWithin(familyTree map[string]Person, name string, hops int) (distance map[string]int, previous map[string]string) {
+	distance = map[string]int{name: 0}
+	previous = make(map[string]string)
+	queue := []string{name}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if distance[current] == hops {
+			continue
+		}
+		for _, next := range neighbors(familyTree, current) {
+			if _, seen := distance[next]; !seen {
+				distance[next] = distance[current] + 1
+				previous[next] = current
+				queue = append(queue, next)
+			}
+		}
+	}
+	return distance, previous
+}
+
+// routeTo returns the people on the path found by routesWithin from its
+// starting person to name, both ends included.
+func routeTo(previous map[string]string, name string) []string {
+	route := []string{name}
+	for {
+		before, ok := previous[route[0]]
+		if !ok {
+			return route
+		}
+		route = append([]string{before}, route...)
+	}
+}
+
+// describeRoute shows the relations followed along route, e.g.
+// "Kiran -> Amit (father) -> KK (father)", naming what each person is to the
+// one before them.
+func describeRoute(familyTree map[string]Person, route []string) string {
+	description := route[0]
+	for i := 1; i < len(route); i++ {
+		description += fmt.Sprintf(" -> %s (%s)", route[i], linkType(familyTree, route[i-1], route[i]))
+	}
+	return description
+}
+
+// linkType returns the relation type other holds towards name, or failing
+// that the reciprocal of the one name holds towards other.
+func linkType(familyTree map[string]Person, name, other string) string {
+	for _, relation := range familyTree[other].Relations {
+		if relation.Target == name {
+			return relation.Type
+		}
+	}
+	for _, relation := range familyTree[name].Relations {
+		if relation.Target == other {
+			if inverse := inverseRelation(relation.Type); inverse != "" {
+				return inverse
+			}
+			return "linked"
+		}
+	}
+	return "linked"
+}
+
+var (
+	maleTypes   = map[string]bool{"son": true, "father": true, "husband": true, "brother": true}
+	femaleTypes = map[string]bool{"daughter": true, "mother": true, "wife": true, "sister": true}
+)
+
+// genderOf returns a person's recorded gender, or else infers it from the
+// relation types they hold, returning "male", "female" or "unknown".
+func genderOf(familyTree map[string]Person, name string) string {
+	if gender := familyTree[name].Gender; gender != "" {
+		return gender
+	}
+	for _, relation := range familyTree[name].Relations {
+		if maleTypes[relation.Type] {
+			return "male"
+		}
+		if femaleTypes[relation.Type] {
+			return "female"
+		}
+	}
+	return "unknown"
+}
+
+// spousesOf returns the sorted names of name's recorded spouses.
+func spousesOf(familyTree map[string]Person, name string) []string {
+	spouses := make(map[string]bool)
+	for _, relation := range familyTree[name].Relations {
+		if spouseTypes[relation.Type] {
+			spouses[relation.Target] = true
+		}
+	}
+	for other, person := range familyTree {
+		for _, relation := range person.Relations {
+			if spouseTypes[relation.Type] && relation.Target == name {
+				spouses[other] = true
+			}
+		}
+	}
+	return existingNames(familyTree, spouses, name)
+}
+
+var spouseTypes = map[string]bool{"wife": true, "husband": true, "spouse": true}
+
+// addRelation records that from is relationType of to, together with the
+// matching reciprocal on to, skipping whichever side is already recorded.
+// Every code path that links two people goes through here so the tree never
+// becomes one-sided.
+func addRelation(familyTree map[string]Person, from, to, relationType string) error {
+	inverse := inverseRelation(relationType)
+	if inverse == "" {
+		return fmt.Errorf("unknown relationship type %q (known types: %s)", relationType, strings.Join(knownRelationTypes(), ", "))
+	}
+
+	appendRelation(familyTree, from, Relation{Type: relationType, Target: to})
+	if !hasReciprocal(familyTree, from, relationType, to) {
+		appendRelation(familyTree, to, Relation{Type: inverse, Target: from})
+	}
+	return nil
+}
+
+// appendRelation adds relation to the named person unless it is already there.
+func appendRelation(familyTree map[string]Person, name string, relation Relation) {
+	person := familyTree[name]
+	for _, existing := range person.Relations {
+		if existing == relation {
+			return
+		}
+	}
+	person.Relations = append(person.Relations, relation)
+	familyTree[name] = person
+}
+
+// maxGenerations caps how many generations up or down the recursive
+// traversals follow, so malformed or very deep trees cannot run away. It is
+// set with --max-generations; generationsTruncated records that a traversal
+// stopped at the cap.
+var (
+	maxGenerations       = 200
+	generationsTruncated bool
+)
+
+// beyondGenerationLimit reports whether generation is past maxGenerations,
+// recording the truncation if it is.
+func beyondGenerationLimit(generation int) bool {
+	if generation > maxGenerations {
+		generationsTruncated = true
+		return true
+	}
+	return false
+}
+
+// descendantDepths returns every descendant of name with the number of
+// generations down to the nearest path reaching them. name itself is not
+// included. Each person is visited once.
+func descendantDepths(familyTree map[string]Person, name string) map[string]int {
+	depth := map[string]int{name: 0}
+	queue := []string{name}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, child := range childrenOf(familyTree, current) {
+			if beyondGenerationLimit(depth[current] + 1) {
+				break
+			}
+			if _, seen := depth[child]; !seen {
+				depth[child] = depth[current] + 1
+				queue = append(queue, child)
+			}
+		}
+	}
+	delete(depth, name)
+	return depth
+}
+
+var siblingTypes = map[string]bool{"brother": true, "sister": true, "sibling": true}
+
+// siblingsOf returns the sorted names of name's siblings: the other children
+// of any of their parents, plus anyone linked to them by an explicit sibling
+// relation. Someone sharing both parents is listed once.
+func siblingsOf(familyTree map[string]Person, name string) []string {
+	siblings := make(map[string]bool)
+	for _, parent := range parentsOf(familyTree, name) {
+		for _, child := range childrenOf(familyTree, parent) {
+			siblings[child] = true
+		}
+	}
+	for _, sibling := range recordedSiblings(familyTree, name) {
+		siblings[sibling] = true
+	}
+	return existingNames(familyTree, siblings, name)
+}
+
+// recordedSiblings returns the sorted names of the people linked to name by an
+// explicit sibling relation, as opposed to siblings implied by shared parents.
+func recordedSiblings(familyTree map[string]Person, name string) []string {
+	siblings := make(map[string]bool)
+	for _, relation := range familyTree[name].Relations {
+		if siblingTypes[relation.Type] {
+			siblings[relation.Target] = true
+		}
+	}
+	for other, person := range familyTree {
+		for _, relation := range person.Relations {
+			if siblingTypes[relation.Type] && relation.Target == name {
+				siblings[other] = true
+			}
+		}
+	}
+	return existingNames(familyTree, siblings, name)
+}
+
+// fatherOf returns the recorded father of name, or "".
+func fatherOf(familyTree map[string]Person, name string) string {
+	return parentInRole(familyTree, name, "father", "male")
+}
+
+// motherOf returns the recorded mother of name, or "".
+func motherOf(familyTree map[string]Person, name string) string {
+	return parentInRole(familyTree, name, "mother", "female")
+}
+
+// parentInRole returns the parent of name recorded as role ("father" or
+// "mother"). A parent who holds that relation towards name wins; otherwise
+// the first parent of the given gender is used, so a child linked to both
+// parents only through son or daughter relations still finds each of them.
+func parentInRole(familyTree map[string]Person, name, role, gender string) string {
+	parents := parentsOf(familyTree, name)
+	for _, parent := range parents {
+		if containsRelation(familyTree[parent].Relations, Relation{Type: role, Target: name}) {
+			return parent
+		}
+	}
+	for _, parent := range parents {
+		if genderOf(familyTree, parent) == gender {
+			return parent
+		}
+	}
+	return ""
+}
+
+// reciprocityGap is a relation whose target does not record the reverse link.
+type reciprocityGap struct {
+	holder, relationType, target string
+}
+
+// reciprocityGaps returns the relations of known types whose target is in the
+// tree but does not link back to the holder.
+func reciprocityGaps(familyTree map[string]Person) []reciprocityGap {
+	var gaps []reciprocityGap
+	for _, name := range sortedNames(familyTree) {
+		for _, relation := range familyTree[name].Relations {
+			if relation.Target == "" || inverseRelation(relation.Type) == "" {
+				continue
+			}
+			if _, exists := familyTree[relation.Target]; !exists {
+				continue
+			}
+			if !hasReciprocal(familyTree, name, relation.Type, relation.Target) {
+				gaps = append(gaps, reciprocityGap{name, relation.Type, relation.Target})
+			}
+		}
+	}
+	return gaps
+}
+
+// danglingRelations counts relations pointing at people missing from the tree.
+func danglingRelations(familyTree map[string]Person) int {
+	count := 0
+	for _, person := range familyTree {
+		for _, relation := range person.Relations {
+			if _, exists := familyTree[relation.Target]; relation.Target != "" && !exists {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// isOrphan reports whether name has no relationships in either direction.
+func isOrphan(familyTree map[string]Person, name string) bool {
+	return len(familyTree[name].Relations) == 0 && len(neighbors(familyTree, name)) == 0
+}
+
+// generationNumbers numbers everyone by generation: 1 for people with no
+// recorded parents, and otherwise one more than their most recent parent, so
+// everyone is below all of their recorded ancestors. People numbered past
+// maxGenerations are left out.
+func generationNumbers(familyTree map[string]Person) map[string]int {
+	generation := make(map[string]int)
+	onStack := make(map[string]bool)
+	var number func(name string) int
+	number = func(name string) int {
+		if g, done := generation[name]; done {
+			return g
+		}
+		if onStack[name] {
+			return 0
+		}
+		onStack[name] = true
+		deepest := 0
+		for _, parent := range parentsOf(familyTree, name) {
+			if g := number(parent); g > deepest {
+				deepest = g
+			}
+		}
+		onStack[name] = false
+		generation[name] = deepest + 1
+		return deepest + 1
+	}
+	for name := range familyTree {
+		number(name)
+	}
+	for name, g := range generation {
+		if beyondGenerationLimit(g) {
+			delete(generation, name)
+		}
+	}
+	return generation
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// chainTree returns a single line of descent G0, G1, ... Gn, where each
+// person is the son of the one before.
+func chainTree(t *testing.T, n int) map[string]Person {
+	t.Helper()
+	familyTree := map[string]Person{}
+	for i := 0; i <= n; i++ {
+		name := fmt.Sprintf("G%d", i)
+		familyTree[name] = Person{Name: name}
+	}
+	for i := 1; i <= n; i++ {
+		if err := addRelation(familyTree, fmt.Sprintf("G%d", i), fmt.Sprintf("G%d", i-1), "son"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return familyTree
+}
+
+// withMaxGenerations sets --max-generations for the rest of the test.
+func withMaxGenerations(t *testing.T, n int) {
+	t.Helper()
+	saved := maxGenerations
+	maxGenerations, generationsTruncated = n, false
+	t.Cleanup(func() { maxGenerations, generationsTruncated = saved, false })
+}
+
+func TestMaxGenerationsTruncatesTraversal(t *testing.T) {
+	familyTree := chainTree(t, 10)
+
+	tests := []struct {
+		name     string
+		walk     func() map[string]int
+		wantSize int
+	}{
+		{"descendants", func() map[string]int { return descendantDepths(familyTree, "G0") }, 3},
+		{"ancestors", func() map[string]int {
+			distance := ancestorDistances(familyTree, "G10")
+			delete(distance, "G10")
+			return distance
+		}, 3},
+		{"nested export", func() map[string]int {
+			root, _ := buildNested(familyTree, "G0", false)
+			depth := make(map[string]int)
+			for node, d := root, 0; len(node.Children) > 0; d++ {
+				node = node.Children[0]
+				depth[node.Name] = d + 1
+			}
+			return depth
+		}, 3},
+		{"generation numbers", func() map[string]int { return generationNumbers(familyTree) }, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withMaxGenerations(t, 3)
+			found := tt.walk()
+			if len(found) != tt.wantSize {
+				t.Errorf("found %d relatives with --max-generations 3, want %d: %v", len(found), tt.wantSize, found)
+			}
+			for relative, generation := range found {
+				if generation > 3 {
+					t.Errorf("%s is %d generations away, beyond the limit of 3", relative, generation)
+				}
+			}
+			if !generationsTruncated {
+				t.Error("generationsTruncated is not set after stopping at the limit")
+			}
+		})
+	}
+
+	t.Run("levels", func(t *testing.T) {
+		withMaxGenerations(t, 3)
+		if got := descendantsAtLevel(familyTree, "G0", 5); len(got) != 0 {
+			t.Errorf("descendantsAtLevel beyond the limit returned %v", got)
+		}
+		if !generationsTruncated {
+			t.Error("generationsTruncated is not set after stopping at the limit")
+		}
+	})
+
+	t.Run("register", func(t *testing.T) {
+		withMaxGenerations(t, 3)
+		report := registerText(familyTree, "G0")
+		if got := strings.Count(report, "\nGeneration "); got != 3 {
+			t.Errorf("register has %d generations with --max-generations 3, want 3:\n%s", got, report)
+		}
+		if !generationsTruncated {
+			t.Error("generationsTruncated is not set after stopping at the limit")
+		}
+	})
+
+	t.Run("within limit", func(t *testing.T) {
+		withMaxGenerations(t, 20)
+		if got := descendantDepths(familyTree, "G0"); len(got) != 10 {
+			t.Errorf("found %d descendants, want all 10", len(got))
+		}
+		if generationsTruncated {
+			t.Error("generationsTruncated is set although the tree is within the limit")
+		}
+	})
+}
+
+func TestFamiliesIgnoreMaxGenerations(t *testing.T) {
+	familyTree := chainTree(t, 5)
+	withMaxGenerations(t, 2)
+
+	if groups := families(familyTree); len(groups) != 1 || len(groups[0]) != 6 {
+		t.Errorf("families = %v, want one family of all 6", groups)
+	}
+	if scoped := scopeToFamily(familyTree, "G1"); len(scoped) != 6 {
+		t.Errorf("scopeToFamily kept %d people, want all 6", len(scoped))
+	}
+	if within := distancesWithin(familyTree, "G0", 4); len(within) != 5 {
+		t.Errorf("distancesWithin 4 hops found %d people, want 5", len(within))
+	}
+	if generationsTruncated {
+		t.Error("generationsTruncated is set by a walk over relation hops")
+	}
+}
+
+func TestAddRelationKeepsTreeReciprocal(t *testing.T) {
+	for _, relationType := range knownRelationTypes() {
+		t.Run(relationType, func(t *testing.T) {
+			familyTree := map[string]Person{"A": {Name: "A"}, "B": {Name: "B"}}
+			for i := 0; i < 2; i++ {
+				if err := addRelation(familyTree, "A", "B", relationType); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if got := familyTree["A"].Relations; len(got) != 1 || got[0] != (Relation{Type: relationType, Target: "B"}) {
+				t.Errorf("A's relations = %v, want just %s of B", got, relationType)
+			}
+			if got := familyTree["B"].Relations; len(got) != 1 || got[0] != (Relation{Type: inverseRelation(relationType), Target: "A"}) {
+				t.Errorf("B's relations = %v, want just %s of A", got, inverseRelation(relationType))
+			}
+			if !hasReciprocal(familyTree, "A", relationType, "B") {
+				t.Errorf("B does not record the reciprocal of A being %s of B", relationType)
+			}
+		})
+	}
+}
+
+func TestAddRelationKeepsExistingReciprocal(t *testing.T) {
+	familyTree := map[string]Person{
+		"KK":   {Name: "KK", Relations: []Relation{{Type: "father", Target: "Amit"}}},
+		"Amit": {Name: "Amit"},
+	}
+	if err := addRelation(familyTree, "Amit", "KK", "son"); err != nil {
+		t.Fatal(err)
+	}
+	if got := familyTree["KK"].Relations; len(got) != 1 {
+		t.Errorf("KK's relations = %v, want the existing father relation only", got)
+	}
+}
+
+func TestAddRelationRejectsUnknownType(t *testing.T) {
+	familyTree := map[string]Person{"A": {Name: "A"}, "B": {Name: "B"}}
+	if err := addRelation(familyTree, "A", "B", "aunt"); err == nil {
+		t.Error("addRelation accepted the unknown type aunt")
+	}
+	if len(familyTree["A"].Relations) != 0 || len(familyTree["B"].Relations) != 0 {
+		t.Errorf("a rejected relation was recorded: %v", familyTree)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+func cite(name, source string) {
+	familyTree := loadFamilyTree()
+
+	person, exists := familyTree[name]
+	if !exists {
+		fmt.Printf("%s is not in the family tree.\n", name)
+		os.Exit(1)
+	}
+
+	for _, existing := range person.Sources {
+		if existing == source {
+			fmt.Printf("%s already cites that source.\n", name)
+			return
+		}
+	}
+
+	person.Sources = append(person.Sources, source)
+	familyTree[name] = person
+	saveFamilyTree(familyTree)
+
+	fmt.Printf("Added a source for %s.\n", name)
+}
+
+// registerText builds a Register-style descendant report for root: each
+// generation in turn, every person numbered in order of appearance, with
+// their children listed under them and sources as numbered footnotes. It
+// stops at generation maxGenerations.
+func registerText(familyTree map[string]Person, root string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Descendants of %s\n", root)
+
+	number := map[string]int{root: 1}
+	next := 2
+	var footnotes []string
+
+	generation := []string{root}
+	for level := 1; len(generation) > 0; level++ {
+		fmt.Fprintf(&b, "\nGeneration %d\n", level)
+
+		var following []string
+		for _, name := range generation {
+			person := familyTree[name]
+			fmt.Fprintf(&b, "\n%d. %s", number[name], name)
+
+			var facts []string
+			if person.Birth != "" {
+				facts = append(facts, "was born "+displayDate(person.Birth))
+			}
+			if person.Death != "" {
+				facts = append(facts, "died "+displayDate(person.Death))
+			}
+			if spouses := spousesOf(familyTree, name); len(spouses) > 0 {
+				facts = append(facts, "married "+joinNames(spouses))
+			}
+			if len(facts) > 0 {
+				b.WriteString(" " + joinNames(facts))
+			}
+			b.WriteString(".")
+
+			for _, source := range person.Sources {
+				footnotes = append(footnotes, source)
+				fmt.Fprintf(&b, "[%d]", len(footnotes))
+			}
+
+			var children []string
+			for _, child := range childrenOf(familyTree, name) {
+				if _, listed := number[child]; !listed {
+					children = append(children, child)
+				}
+			}
+			if len(children) > 0 && beyondGenerationLimit(level+1) {
+				children = nil
+			}
+			if len(children) > 0 {
+				fmt.Fprintf(&b, " %s had %d %s:", name, len(children), plural(len(children), "child"))
+			}
+			b.WriteString("\n")
+
+			for _, child := range children {
+				number[child] = next
+				next++
+				fmt.Fprintf(&b, "   %d. %s\n", number[child], child)
+				following = append(following, child)
+			}
+		}
+		generation = following
+	}
+
+	if len(footnotes) > 0 {
+		b.WriteString("\nSources\n")
+		for i, source := range footnotes {
+			fmt.Fprintf(&b, "[%d] %s\n", i+1, source)
+		}
+	}
+	return b.String()
+}
+
+// joinNames joins items as "a", "a and b" or "a, b and c".
+func joinNames(items []string) string {
+	if len(items) <= 1 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
+
+// wrapText wraps each line of text at word boundaries so no line is longer
+// than width, keeping a line's leading indentation on its continuation lines.
+func wrapText(text string, width int) string {
+	var b strings.Builder
+	for _, line := range strings.SplitAfter(text, "\n") {
+		newline := strings.HasSuffix(line, "\n")
+		line = strings.TrimSuffix(line, "\n")
+		indent := line[:len(line)-len(strings.TrimLeft(line, " "))]
+
+		length := 0
+		for i, word := range strings.Fields(line) {
+			switch {
+			case i == 0:
+				b.WriteString(indent + word)
+				length = len(indent) + len(word)
+			case length+1+len(word) > width:
+				b.WriteString("\n" + indent + word)
+				length = len(indent) + len(word)
+			default:
+				b.WriteString(" " + word)
+				length += 1 + len(word)
+			}
+		}
+		if newline {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// outputWidth returns the terminal width, or 80 columns when standard
+// output is not a terminal whose size can be read.
+func outputWidth() int {
+	if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && width > 0 {
+		return width
+	}
+	return 80
+}
+
+func exportRegisterText(name string, width int) {
+	familyTree := loadFamilyTree()
+
+	if _, exists := familyTree[name]; !exists {
+		fmt.Printf("%s is not in the family tree.\n", name)
+		os.Exit(1)
+	}
+
+	fmt.Print(wrapText(registerText(familyTree, name), width))
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// currentSchemaVersion is the newest family tree file layout this binary
+// understands. Version 1 files are a bare map of people whose relations are
+// plain strings; they predate the schemaVersion field.
+const currentSchemaVersion = 2
+
+// familyTreeDocument is the on-disk layout of the family tree file.
+type familyTreeDocument struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	People        map[string]Person `json:"people"`
+}
+
+// legacyPerson is a person as stored by schema version 1.
+type legacyPerson struct {
+	Name      string   `json:"name"`
+	Relations []string `json:"relations"`
+}
+
+// encodeFamilyTree produces the file contents for a family tree. The output is
+// deterministic: encoding/json sorts the people by key, tags are sorted, and
+// relations are sorted by type and then target with exact duplicates dropped,
+// so equivalent trees always encode to identical bytes and the file diffs
+// cleanly.
+func encodeFamilyTree(familyTree map[string]Person) ([]byte, error) {
+	doc := familyTreeDocument{SchemaVersion: currentSchemaVersion, People: canonicalPeople(familyTree)}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// encodeFamilyTreeInOrder is encodeFamilyTree writing the people in the given
+// order instead of sorted by name. order must list everyone in familyTree.
+func encodeFamilyTreeInOrder(familyTree map[string]Person, order []string) ([]byte, error) {
+	people := canonicalPeople(familyTree)
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "{\n  \"schemaVersion\": %d,\n  \"people\": {", currentSchemaVersion)
+	for i, name := range order {
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.MarshalIndent(people[name], "    ", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, "\n    %s: %s", key, value)
+	}
+	if len(order) > 0 {
+		b.WriteString("\n  ")
+	}
+	b.WriteString("}\n}")
+	return b.Bytes(), nil
+}
+
+// canonicalPeople returns a copy of familyTree with each person's tags and
+// relations in the canonical order encodeFamilyTree writes.
+func canonicalPeople(familyTree map[string]Person) map[string]Person {
+	people := make(map[string]Person, len(familyTree))
+	for key, person := range familyTree {
+		relations := make([]Relation, len(person.Relations))
+		copy(relations, person.Relations)
+		sort.SliceStable(relations, func(i, j int) bool {
+			if relations[i].Type != relations[j].Type {
+				return relations[i].Type < relations[j].Type
+			}
+			return relations[i].Target < relations[j].Target
+		})
+		person.Relations = dedupeSorted(relations)
+
+		if person.Tags != nil {
+			tags := make([]string, len(person.Tags))
+			copy(tags, person.Tags)
+			sort.Strings(tags)
+			person.Tags = tags
+		}
+		people[key] = person
+	}
+	return people
+}
+
+// dedupeSorted drops repeats from sorted relations, keeping the order.
+func dedupeSorted(relations []Relation) []Relation {
+	unique := relations[:0]
+	for i, relation := range relations {
+		if i == 0 || relation != relations[i-1] {
+			unique = append(unique, relation)
+		}
+	}
+	return unique
+}
+
+// decodeFamilyTree decodes family tree data of any supported schema version,
+// upgrading older layouts to the current one.
+func decodeFamilyTree(data []byte) (map[string]Person, error) {
+	version, err := schemaVersion(data)
+	if err != nil {
+		return nil, err
+	}
+	if version > currentSchemaVersion {
+		return nil, fmt.Errorf("the family tree file uses schema version %d, but this version of family-tree only supports up to %d; please upgrade family-tree", version, currentSchemaVersion)
+	}
+
+	if version == 1 {
+		return migrateV1(data)
+	}
+
+	var doc familyTreeDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.People == nil {
+		doc.People = make(map[string]Person)
+	}
+	return doc.People, nil
+}
+
+// schemaVersion reports the schema version of family tree data. A version 1
+// file is a map of people, so a "schemaVersion" entry there would be a person
+// object rather than a number.
+func schemaVersion(data []byte) (int, error) {
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(data, &top); err != nil {
+		return 0, err
+	}
+
+	var version int
+	if raw, ok := top["schemaVersion"]; ok && json.Unmarshal(raw, &version) == nil {
+		return version, nil
+	}
+	return 1, nil
+}
+
+// migrateV1 converts string relations to Relation values. Version 1 files
+// never recorded who a relation points to, so there is no target to infer
+// and migrated relations keep an empty Target.
+func migrateV1(data []byte) (map[string]Person, error) {
+	var legacy map[string]legacyPerson
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return nil, err
+	}
+
+	familyTree := make(map[string]Person, len(legacy))
+	for key, old := range legacy {
+		relations := make([]Relation, 0, len(old.Relations))
+		for _, relation := range old.Relations {
+			relations = append(relations, Relation{Type: relation})
+		}
+		familyTree[key] = Person{Name: old.Name, Relations: relations}
+	}
+	return familyTree, nil
+}
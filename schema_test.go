@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncodeFamilyTreeIsCanonical(t *testing.T) {
+	a := map[string]Person{
+		"KK": {Name: "KK", Tags: []string{"paternal", "elder"}, Relations: []Relation{
+			{Type: "parent", Target: "Raj"},
+			{Type: "parent", Target: "Amit"},
+		}},
+		"Amit": {Name: "Amit", Relations: []Relation{{Type: "son", Target: "KK"}}},
+		"Raj":  {Name: "Raj", Relations: []Relation{{Type: "son", Target: "KK"}, {Type: "brother", Target: "Amit"}}},
+	}
+	// The same tree built in a different order, with a duplicate relation.
+	b := map[string]Person{
+		"Raj":  {Name: "Raj", Relations: []Relation{{Type: "brother", Target: "Amit"}, {Type: "son", Target: "KK"}}},
+		"Amit": {Name: "Amit", Relations: []Relation{{Type: "son", Target: "KK"}, {Type: "son", Target: "KK"}}},
+		"KK": {Name: "KK", Tags: []string{"elder", "paternal"}, Relations: []Relation{
+			{Type: "parent", Target: "Amit"},
+			{Type: "parent", Target: "Raj"},
+		}},
+	}
+
+	encodedA, err := encodeFamilyTree(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encodedB, err := encodeFamilyTree(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(encodedA, encodedB) {
+		t.Errorf("equivalent trees encoded differently:\n%s\n---\n%s", encodedA, encodedB)
+	}
+}
+
+// useTempFamilyTree points the commands at an empty family tree file in a
+// temporary directory for the rest of the test.
+func useTempFamilyTree(t *testing.T) {
+	t.Helper()
+	saved := familyTreeFile
+	familyTreeFile = filepath.Join(t.TempDir(), "family_tree.json")
+	t.Cleanup(func() { familyTreeFile = saved })
+	createFamilyTreeFile()
+}
+
+func TestCanonicalFormSurvivesMutations(t *testing.T) {
+	useTempFamilyTree(t)
+	for _, name := range []string{"Amit", "KK", "Raj", "Gita"} {
+		addPerson(name)
+	}
+	connectPeople("Amit", "son", "KK")
+	connectPeople("Raj", "son", "KK")
+	connectPeople("Gita", "wife", "KK")
+	connectPeople("Raj", "brother", "Amit")
+	removePerson("Gita")
+	first, err := os.ReadFile(familyTreeFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The same tree reached in another order, repeating a connection and
+	// adding and removing someone else along the way.
+	useTempFamilyTree(t)
+	for _, name := range []string{"Raj", "Sita", "KK", "Amit"} {
+		addPerson(name)
+	}
+	connectPeople("Raj", "brother", "Amit")
+	connectPeople("Sita", "daughter", "KK")
+	connectPeople("Raj", "son", "KK")
+	connectPeople("Amit", "son", "KK")
+	connectPeople("Amit", "son", "KK")
+	removePerson("Sita")
+	second, err := os.ReadFile(familyTreeFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(first, second) {
+		t.Errorf("the same tree reached by different mutations encoded differently:\n%s\n---\n%s", first, second)
+	}
+
+	// Loading and saving again must not change a canonical file.
+	saveFamilyTree(loadFamilyTree())
+	resaved, err := os.ReadFile(familyTreeFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(second, resaved) {
+		t.Errorf("re-saving changed the canonical form:\n%s\n---\n%s", second, resaved)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// printSiblings lists name's siblings, marking half-siblings: those sharing
+// only one of the two parents recorded for either of them.
+func printSiblings(name string) {
+	familyTree := loadFamilyTree()
+
+	if _, exists := familyTree[name]; !exists {
+		fmt.Printf("%s is not in the family tree.\n", name)
+		os.Exit(1)
+	}
+
+	siblings := siblingsOf(familyTree, name)
+	if len(siblings) == 0 {
+		fmt.Printf("%s has no recorded siblings.\n", name)
+		return
+	}
+
+	parents := parentsOf(familyTree, name)
+	fmt.Printf("Siblings of %s:\n", name)
+	for _, sibling := range siblings {
+		theirParents := parentsOf(familyTree, sibling)
+		shared := 0
+		for _, parent := range theirParents {
+			if contains(parents, parent) {
+				shared++
+			}
+		}
+		if shared == 1 && (len(parents) > 1 || len(theirParents) > 1) {
+			fmt.Printf("  %s (half-sibling)\n", sibling)
+		} else {
+			fmt.Printf("  %s\n", sibling)
+		}
+	}
+}
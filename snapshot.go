@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+var snapshotLabel = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// snapshotDir is where named snapshots of the family tree file are kept: a
+// directory next to it, so each file has its own snapshots.
+func snapshotDir() string {
+	return familyTreeFile + ".snapshots"
+}
+
+// snapshotPath returns the file a snapshot is stored in, keeping the family
+// tree file's extension so a compressed file stays recognisable.
+func snapshotPath(label string) string {
+	return filepath.Join(snapshotDir(), label+filepath.Ext(familyTreeFile))
+}
+
+// takeSnapshot copies the family tree file, as stored on disk, to a snapshot
+// named label.
+func takeSnapshot(label string) {
+	if !snapshotLabel.MatchString(label) {
+		fmt.Println("Snapshot labels may only contain letters, digits, '.', '_' and '-'.")
+		os.Exit(1)
+	}
+	path := snapshotPath(label)
+	if _, err := os.Stat(path); err == nil {
+		fmt.Printf("Snapshot '%s' already exists.\n", label)
+		os.Exit(1)
+	}
+
+	data, err := os.ReadFile(familyTreeFile)
+	if err != nil {
+		fmt.Printf("Error reading family tree file: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.MkdirAll(snapshotDir(), 0755); err != nil {
+		fmt.Printf("Error creating snapshot directory: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		fmt.Printf("Error writing snapshot: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Saved snapshot '%s' to %s.\n", label, path)
+}
+
+func listSnapshots() {
+	pattern := filepath.Join(snapshotDir(), "*"+filepath.Ext(familyTreeFile))
+	paths, _ := filepath.Glob(pattern)
+	if len(paths) == 0 {
+		fmt.Println("No snapshots saved.")
+		return
+	}
+
+	type snapshot struct {
+		label string
+		info  os.FileInfo
+	}
+	var snapshots []snapshot
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		label := filepath.Base(path)
+		snapshots = append(snapshots, snapshot{label[:len(label)-len(filepath.Ext(familyTreeFile))], info})
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].info.ModTime().Before(snapshots[j].info.ModTime()) })
+
+	for _, s := range snapshots {
+		fmt.Printf("  %-25s %s  %d bytes\n", s.label, s.info.ModTime().Format("2006-01-02 15:04"), s.info.Size())
+	}
+}
+
+// restoreSnapshot replaces the family tree file with the snapshot named
+// label, after confirmation and a backup of the current file.
+func restoreSnapshot(label string) {
+	checkWritable()
+	if batch != nil {
+		fmt.Println("Snapshots cannot be restored in the middle of a batch.")
+		os.Exit(1)
+	}
+
+	if !snapshotLabel.MatchString(label) {
+		fmt.Printf("There is no snapshot '%s'. Use 'snapshot list' to see them.\n", label)
+		os.Exit(1)
+	}
+	data, err := os.ReadFile(snapshotPath(label))
+	if err != nil {
+		fmt.Printf("There is no snapshot '%s'. Use 'snapshot list' to see them.\n", label)
+		os.Exit(1)
+	}
+
+	if !confirm(fmt.Sprintf("Replace %s with snapshot '%s'?", familyTreeFile, label)) {
+		fmt.Println("Nothing restored.")
+		return
+	}
+
+	backup, err := backupFamilyTreeFile()
+	if err != nil {
+		fmt.Printf("Error backing up family tree file: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(familyTreeFile, data, 0644); err != nil {
+		fmt.Printf("Error writing family tree file: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Restored snapshot '%s'. Backup saved to %s.\n", label, backup)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// checkSpouses reports spouse relations recorded on only one partner. With
+// monogamy it also reports people with more than one spouse whose marriages
+// might have overlapped. Marriage and divorce dates are not recorded, so
+// marriages only count as sequential when every spouse but one has a
+// recorded death date.
+func checkSpouses(monogamy bool) {
+	familyTree := loadFamilyTree()
+
+	anomalies := 0
+	for _, gap := range reciprocityGaps(familyTree) {
+		if !spouseTypes[gap.relationType] {
+			continue
+		}
+		fmt.Printf("%s is %s of %s, but %s does not list %s as a spouse.\n",
+			gap.holder, gap.relationType, gap.target, gap.target, gap.holder)
+		anomalies++
+	}
+
+	if monogamy {
+		for _, name := range sortedNames(familyTree) {
+			spouses := spousesOf(familyTree, name)
+			if len(spouses) < 2 {
+				continue
+			}
+			var undated []string
+			for _, spouse := range spouses {
+				if _, ok := personDate(familyTree[spouse].Death); !ok {
+					undated = append(undated, spouse)
+				}
+			}
+			if len(undated) < 2 {
+				continue
+			}
+			fmt.Printf("%s has %d spouses (%s) and nothing to show the marriages did not overlap.\n",
+				name, len(spouses), strings.Join(spouses, ", "))
+			anomalies++
+		}
+	}
+
+	if anomalies == 0 {
+		fmt.Println("No spouse problems found.")
+		return
+	}
+	fmt.Printf("\n%d spouse %s found.\n", anomalies, plural(anomalies, "problem"))
+}
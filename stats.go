@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+)
+
+// statsReport builds the summary printed by stats.
+func statsReport(familyTree map[string]Person) string {
+	var b strings.Builder
+
+	genders := make(map[string]int)
+	living, withBirth := 0, 0
+	for name, person := range familyTree {
+		genders[genderOf(familyTree, name)]++
+		if person.Death == "" {
+			living++
+		}
+		if person.Birth != "" {
+			withBirth++
+		}
+	}
+	generations := 0
+	for _, generation := range generationNumbers(familyTree) {
+		if generation > generations {
+			generations = generation
+		}
+	}
+	relations := 0
+	for _, person := range familyTree {
+		relations += len(person.Relations)
+	}
+	people := len(familyTree)
+
+	fmt.Fprintf(&b, "People:          %d\n", people)
+	fmt.Fprintf(&b, "  Male:          %d\n", genders["male"])
+	fmt.Fprintf(&b, "  Female:        %d\n", genders["female"])
+	fmt.Fprintf(&b, "  Unknown:       %d\n", genders["unknown"])
+	fmt.Fprintf(&b, "No death date:   %d\n", living)
+	fmt.Fprintf(&b, "With birth date: %d (%.0f%%)\n", withBirth, percent(withBirth, people))
+	fmt.Fprintf(&b, "Relations:       %d\n", relations)
+	fmt.Fprintf(&b, "Generations:     %d\n", generations)
+	fmt.Fprintf(&b, "Families:        %d\n", len(families(familyTree)))
+	return b.String()
+}
+
+func printStats() {
+	familyTree := loadFamilyTree()
+	fmt.Print(statsReport(familyTree))
+	noteSeparateFamilies(familyTree)
+}
+
+// watchStats reprints the stats every interval until interrupted. A file
+// caught half-written is reported and retried on the next tick instead of
+// ending the dashboard.
+func watchStats(interval time.Duration) {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		fmt.Print(clearScreen)
+		fmt.Printf("Family tree stats for %s, every %s  (%s)\n\n", familyTreeFile, interval, time.Now().Format("15:04:05"))
+		if familyTree, err := readStats(); err != nil {
+			fmt.Printf("Could not read the family tree file: %v\nRetrying...\n", err)
+		} else {
+			fmt.Print(statsReport(familyTree))
+		}
+
+		select {
+		case <-interrupt:
+			fmt.Println()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// readStats loads the family tree like loadFamilyTree, but returns errors
+// instead of exiting.
+func readStats() (map[string]Person, error) {
+	data, err := readFamilyTreeFile()
+	if err != nil {
+		return nil, err
+	}
+	familyTree, err := decodeFamilyTree(data)
+	if err != nil {
+		return nil, err
+	}
+	if rootScope != "" {
+		if _, exists := familyTree[rootScope]; !exists {
+			return nil, personNotFound(rootScope)
+		}
+		familyTree = scopeToFamily(familyTree, rootScope)
+	}
+	return familyTree, nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+func addTag(name, tag string) {
+	familyTree := loadFamilyTree()
+
+	person, exists := familyTree[name]
+	if !exists {
+		fmt.Printf("%s is not in the family tree.\n", name)
+		os.Exit(1)
+	}
+
+	if hasTag(person, tag) {
+		fmt.Printf("%s is already tagged '%s'.\n", name, tag)
+		return
+	}
+
+	person.Tags = append(person.Tags, tag)
+	familyTree[name] = person
+	saveFamilyTree(familyTree)
+
+	fmt.Printf("Tagged %s as '%s'.\n", name, tag)
+}
+
+func removeTag(name, tag string) {
+	familyTree := loadFamilyTree()
+
+	person, exists := familyTree[name]
+	if !exists {
+		fmt.Printf("%s is not in the family tree.\n", name)
+		os.Exit(1)
+	}
+
+	if !hasTag(person, tag) {
+		fmt.Printf("%s is not tagged '%s'.\n", name, tag)
+		return
+	}
+
+	tags := []string{}
+	for _, existing := range person.Tags {
+		if existing != tag {
+			tags = append(tags, existing)
+		}
+	}
+	person.Tags = tags
+	familyTree[name] = person
+	saveFamilyTree(familyTree)
+
+	fmt.Printf("Removed tag '%s' from %s.\n", tag, name)
+}
+
+// findByTag returns the sorted names of everyone carrying the tag.
+func findByTag(tag string) []string {
+	familyTree := loadFamilyTree()
+
+	var names []string
+	for name, person := range familyTree {
+		if hasTag(person, tag) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func hasTag(person Person, tag string) bool {
+	for _, existing := range person.Tags {
+		if existing == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// longestLine prints the longest chain of parent links in the family tree,
+// from the earliest ancestor down to the most recent descendant.
+func longestLine() {
+	familyTree := loadFamilyTree()
+
+	// A person's generation number is the number of people in the longest
+	// line ending at them.
+	depth := generationNumbers(familyTree)
+
+	longest, bottom := 0, ""
+	for _, name := range sortedNames(familyTree) {
+		if d := depth[name]; d > longest {
+			longest, bottom = d, name
+		}
+	}
+
+	if longest < 2 {
+		fmt.Println("No parent links are recorded in the family tree.")
+		return
+	}
+
+	// Climb back up from the bottom, always taking the deepest parent.
+	line := []string{bottom}
+	for name := bottom; depth[name] > 1; {
+		for _, parent := range parentsOf(familyTree, name) {
+			if depth[parent] == depth[name]-1 {
+				name = parent
+				break
+			}
+		}
+		line = append([]string{name}, line...)
+	}
+
+	fmt.Printf("Longest line (%d generations):\n", longest)
+	fmt.Println("  " + strings.Join(line, " -> "))
+
+	if others := countLines(familyTree, depth, longest) - 1; others > 0 {
+		fmt.Printf("%d other lines of the same length exist.\n", others)
+	}
+	noteSeparateFamilies(familyTree)
+}
+
+// countLines counts the distinct parent chains of the given length, using the
+// generation numbers longestLine works from.
+func countLines(familyTree map[string]Person, depth map[string]int, length int) int {
+	ways := make(map[string]int)
+	var count func(name string) int
+	count = func(name string) int {
+		if depth[name] == 1 {
+			return 1
+		}
+		if w, done := ways[name]; done {
+			return w
+		}
+		total := 0
+		for _, parent := range parentsOf(familyTree, name) {
+			if depth[parent] == depth[name]-1 {
+				total += count(parent)
+			}
+		}
+		ways[name] = total
+		return total
+	}
+
+	total := 0
+	for name, d := range depth {
+		if d == length {
+			total += count(name)
+		}
+	}
+	return total
+}
+
+// ancestorGenerationName names the ancestors n generations up: parents,
+// grandparents, great-grandparents and so on.
+func ancestorGenerationName(n int) string {
+	if n == 1 {
+		return "parents"
+	}
+	return strings.Repeat("great-", n-2) + "grandparents"
+}
+
+func printAncestorsAtLevel(name string, level int, withSpouses bool) {
+	familyTree := loadFamilyTree()
+
+	if _, exists := familyTree[name]; !exists {
+		fmt.Printf("%s is not in the family tree.\n", name)
+		os.Exit(1)
+	}
+
+	group := ancestorGenerationName(level)
+	ancestors := ancestorsAtLevel(familyTree, name, level)
+	if len(ancestors) == 0 {
+		fmt.Printf("%s has no %s recorded.\n", name, group)
+		return
+	}
+
+	fmt.Printf("%s of %s:\n", strings.ToUpper(group[:1])+group[1:], name)
+	for _, ancestor := range ancestors {
+		fmt.Printf("  %s (%s)%s\n", ancestor, bloodLabel(level, 0, genderOf(familyTree, ancestor)), spouseNote(familyTree, ancestor, withSpouses))
+	}
+}
+
+// printLineage follows only father links (paternal) or only mother links
+// (maternal) up from name and prints the chain to the earliest known ancestor.
+func printLineage(name, line string, withSpouses bool) {
+	familyTree := loadFamilyTree()
+
+	if _, exists := familyTree[name]; !exists {
+		fmt.Printf("%s is not in the family tree.\n", name)
+		os.Exit(1)
+	}
+
+	lines := []string{line}
+	if line == "both" {
+		lines = []string{"paternal", "maternal"}
+	}
+
+	for i, line := range lines {
+		if i > 0 {
+			fmt.Println()
+		}
+
+		gender, parentWord := "male", "father"
+		if line == "maternal" {
+			gender, parentWord = "female", "mother"
+		}
+
+		fmt.Printf("%s line of %s:\n", strings.ToUpper(line[:1])+line[1:], name)
+		fmt.Printf("  %s%s\n", name, spouseNote(familyTree, name, withSpouses))
+		visited := map[string]bool{name: true}
+		current := name
+		for generation := 1; ; generation++ {
+			parent := parentInRole(familyTree, current, parentWord, gender)
+			if parent == "" {
+				fmt.Printf("The line ends at %s: no %s recorded.\n", current, parentWord)
+				break
+			}
+			if visited[parent] {
+				fmt.Printf("The line loops back to %s; stopping.\n", parent)
+				break
+			}
+			if beyondGenerationLimit(generation) {
+				break
+			}
+			visited[parent] = true
+			fmt.Printf("  %s (%s)%s\n", parent, bloodLabel(generation, 0, gender), spouseNote(familyTree, parent, withSpouses))
+			current = parent
+		}
+	}
+}
+
+func printDescendantsAtLevel(name string, level int, withSpouses bool) {
+	familyTree := loadFamilyTree()
+
+	if _, exists := familyTree[name]; !exists {
+		fmt.Printf("%s is not in the family tree.\n", name)
+		os.Exit(1)
+	}
+
+	// Branches that end before the level simply contribute no one.
+	group := descendantGenerationName(level)
+	descendants := descendantsAtLevel(familyTree, name, level)
+	if len(descendants) == 0 {
+		fmt.Printf("%s has no %s recorded.\n", name, group)
+		return
+	}
+
+	fmt.Printf("%s of %s:\n", strings.ToUpper(group[:1])+group[1:], name)
+	for _, descendant := range descendants {
+		fmt.Printf("  %s (%s)%s\n", descendant, bloodLabel(0, level, genderOf(familyTree, descendant)), spouseNote(familyTree, descendant, withSpouses))
+	}
+}
+
+// spouseNote returns ", married to" and the spouses of name for the
+// --with-spouses option of the generation reports, or "" when there are none
+// or the option is off. Marriage dates are not recorded, so none are shown.
+func spouseNote(familyTree map[string]Person, name string, withSpouses bool) string {
+	if !withSpouses {
+		return ""
+	}
+	spouses := spousesOf(familyTree, name)
+	if len(spouses) == 0 {
+		return ""
+	}
+	return ", married to " + joinNames(spouses)
+}
+
+// printAncestors lists all of name's ancestors grouped by generation:
+// parents, grandparents, great-grandparents and so on. An ancestor reachable
+// along several lines is listed in the nearest generation only.
+func printAncestors(name string) {
+	familyTree := loadFamilyTree()
+
+	if _, exists := familyTree[name]; !exists {
+		fmt.Printf("%s is not in the family tree.\n", name)
+		os.Exit(1)
+	}
+
+	byGeneration := make(map[int][]string)
+	deepest := 0
+	for ancestor, generation := range ancestorDistances(familyTree, name) {
+		if ancestor == name {
+			continue
+		}
+		byGeneration[generation] = append(byGeneration[generation], ancestor)
+		if generation > deepest {
+			deepest = generation
+		}
+	}
+	if deepest == 0 {
+		fmt.Printf("%s has no recorded ancestors.\n", name)
+		return
+	}
+
+	fmt.Printf("Ancestors of %s:\n", name)
+	for generation := 1; generation <= deepest; generation++ {
+		if len(byGeneration[generation]) == 0 {
+			continue
+		}
+		group := ancestorGenerationName(generation)
+		fmt.Printf("  %s:\n", strings.ToUpper(group[:1])+group[1:])
+		sort.Strings(byGeneration[generation])
+		for _, ancestor := range byGeneration[generation] {
+			fmt.Printf("    %s (%s)\n", ancestor, bloodLabel(generation, 0, genderOf(familyTree, ancestor)))
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// treeGlyphs are the connectors drawn in front of each line of a tree chart.
+type treeGlyphs struct {
+	branch, last, pipe, space string
+}
+
+var (
+	asciiGlyphs   = treeGlyphs{"|-- ", "`-- ", "|   ", "    "}
+	unicodeGlyphs = treeGlyphs{"├── ", "└── ", "│   ", "    "}
+)
+
+// treeStyle picks the glyphs for style, which is "ascii", "unicode" or "" to
+// use box-drawing characters only when writing to a UTF-8 terminal.
+func treeStyle(style string) (treeGlyphs, error) {
+	switch style {
+	case "ascii":
+		return asciiGlyphs, nil
+	case "unicode":
+		return unicodeGlyphs, nil
+	case "":
+		if term.IsTerminal(int(os.Stdout.Fd())) && utf8Locale() {
+			return unicodeGlyphs, nil
+		}
+		return asciiGlyphs, nil
+	}
+	return treeGlyphs{}, fmt.Errorf("unknown tree style '%s'; use ascii or unicode", style)
+}
+
+// utf8Locale reports whether the locale environment asks for UTF-8, checking
+// the variables in the order the C library gives them precedence.
+func utf8Locale() bool {
+	for _, variable := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if value := os.Getenv(variable); value != "" {
+			value = strings.ToLower(value)
+			return strings.Contains(value, "utf-8") || strings.Contains(value, "utf8")
+		}
+	}
+	return false
+}
+
+// treeNode is one person in the descendant chart. Someone reachable along
+// two lines of descent appears in full only the first time; later
+// appearances are marked Revisited and carry no children.
+type treeNode struct {
+	Name      string      `json:"name"`
+	Depth     int         `json:"depth"`
+	Revisited bool        `json:"revisited,omitempty"`
+	Children  []*treeNode `json:"children,omitempty"`
+}
+
+// buildTree collects name and their descendants, down to maxGenerations.
+func buildTree(familyTree map[string]Person, name string) *treeNode {
+	seen := map[string]bool{name: true}
+	var build func(person string, depth int) *treeNode
+	build = func(person string, depth int) *treeNode {
+		node := &treeNode{Name: person, Depth: depth}
+		children := childrenOf(familyTree, person)
+		if len(children) > 0 && beyondGenerationLimit(depth+1) {
+			return node
+		}
+		for _, child := range children {
+			if seen[child] {
+				node.Children = append(node.Children, &treeNode{Name: child, Depth: depth + 1, Revisited: true})
+				continue
+			}
+			seen[child] = true
+			node.Children = append(node.Children, build(child, depth+1))
+		}
+		return node
+	}
+	return build(name, 0)
+}
+
+// printTree shows name and their descendants, either drawn as an indented
+// chart with one person per line or, with asJSON, as nested JSON objects.
+func printTree(name, style string, asJSON bool) {
+	glyphs, err := treeStyle(style)
+	if err != nil {
+		fmt.Printf("Invalid style: %v.\n", err)
+		os.Exit(1)
+	}
+
+	familyTree := loadFamilyTree()
+	if _, exists := familyTree[name]; !exists {
+		fmt.Printf("%s is not in the family tree.\n", name)
+		os.Exit(1)
+	}
+
+	root := buildTree(familyTree, name)
+	if asJSON {
+		data, err := json.MarshalIndent(root, "", "  ")
+		if err != nil {
+			fmt.Printf("Error encoding family tree data: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	fmt.Println(root.Name)
+	var draw func(node *treeNode, prefix string)
+	draw = func(node *treeNode, prefix string) {
+		for i, child := range node.Children {
+			connector, indent := glyphs.branch, glyphs.pipe
+			if i == len(node.Children)-1 {
+				connector, indent = glyphs.last, glyphs.space
+			}
+			if child.Revisited {
+				fmt.Printf("%s%s%s (see above)\n", prefix, connector, child.Name)
+				continue
+			}
+			fmt.Printf("%s%s%s\n", prefix, connector, child.Name)
+			draw(child, prefix+indent)
+		}
+	}
+	draw(root, "")
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const clearScreen = "\033[H\033[2J"
+
+// watch runs query, then polls the family tree file and re-runs the query,
+// on a cleared screen, each time the file changes. The query runs as a
+// read-only child process so a failing query does not end the watch.
+func watch(query []string, interval time.Duration) {
+	executable, err := os.Executable()
+	if err != nil {
+		fmt.Printf("Error locating the family-tree executable: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Ask for the passphrase of an encrypted file once, not on every refresh.
+	if data, err := os.ReadFile(familyTreeFile); err == nil && isEncrypted(data) && os.Getenv(passphraseEnv) == "" {
+		pass, err := passphrase()
+		if err != nil {
+			fmt.Printf("Error reading family tree file: %v\n", err)
+			os.Exit(1)
+		}
+		os.Setenv(passphraseEnv, pass)
+	}
+
+	args := append(append(append([]string{}, globalArgs...), "--read-only"), query...)
+	run := func() {
+		fmt.Print(clearScreen)
+		fmt.Printf("Every %s: family-tree %s  (%s)\n\n", interval, joinArgs(query), time.Now().Format("15:04:05"))
+		cmd := exec.Command(executable, args...)
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			// Typically the file was caught half-written; the next change
+			// triggers another run.
+			fmt.Printf("\n(query failed: %v; waiting for the next change)\n", err)
+		}
+	}
+
+	var lastModified time.Time
+	if info, err := os.Stat(familyTreeFile); err == nil {
+		lastModified = info.ModTime()
+	}
+	run()
+
+	for range time.Tick(interval) {
+		info, err := os.Stat(familyTreeFile)
+		if err != nil {
+			// The file may be briefly missing while an editor replaces it.
+			continue
+		}
+		if info.ModTime().Equal(lastModified) {
+			continue
+		}
+		lastModified = info.ModTime()
+		run()
+	}
+}
+
+// joinArgs joins command arguments for display, quoting those with spaces.
+func joinArgs(args []string) string {
+	display := ""
+	for i, arg := range args {
+		if i > 0 {
+			display += " "
+		}
+		if strings.ContainsAny(arg, " \t") {
+			arg = fmt.Sprintf("%q", arg)
+		}
+		display += arg
+	}
+	return display
+}